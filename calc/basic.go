@@ -29,7 +29,8 @@ type BasicContext struct {
 	GbbqIndex GbbqIndex
 }
 
-// ExportBasicDailyToCSV 计算并导出 BasicDaily 数据 (覆盖 stock + etf)。
+// ExportBasicDailyToCSV 计算并导出 BasicDaily 数据 (覆盖 stock + bstock + etf + index + block)。
+// 指数与板块没有 gbbq 记录，只有前收、涨跌幅与振幅，换手率与市值为 0。
 func ExportBasicDailyToCSV(
 	ctx context.Context,
 	db database.DataRepository,
@@ -42,7 +43,7 @@ func ExportBasicDailyToCSV(
 	}
 	gbbqIndex := buildGbbqIndex(gbbqData)
 
-	symbols, err := db.GetSymbolsByClass(model.ClassStock, model.ClassETF)
+	symbols, err := db.GetSymbolsByClass(model.ClassStock, model.ClassBStock, model.ClassETF, model.ClassIndex, model.ClassBlock)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query symbols: %w", err)
 	}
@@ -58,6 +59,7 @@ func ExportBasicDailyToCSV(
 		GbbqIndex: gbbqIndex,
 	}
 
+	log := utils.Logger(ctx)
 	pipeline := utils.NewPipeline[string, model.BasicDaily]()
 
 	result, err := pipeline.Run(
@@ -69,7 +71,11 @@ func ExportBasicDailyToCSV(
 				return nil, ctx.Err()
 			default:
 			}
-			return processBasicDaily(basicCtx, symbol)
+			rows, err := processBasicDaily(basicCtx, symbol)
+			if err != nil {
+				log.Warn("basic 计算失败", "symbol", symbol, "error", err)
+			}
+			return rows, err
 		},
 		func(rows []model.BasicDaily) error {
 			return cw.Write(rows)
@@ -80,6 +86,9 @@ func ExportBasicDailyToCSV(
 		return 0, err
 	}
 
+	log.Debug("basic 计算完成", "symbols", result.TotalItems,
+		"rows", result.OutputRows, "duration", result.Duration)
+
 	if result.HasErrors() {
 		return 0, fmt.Errorf("export completed with %s", result.ErrorSummary())
 	}
@@ -160,7 +169,7 @@ func CalculateBasicDaily(
 			if idx, ok := findIdx(item.Date); ok {
 				mergeSplitFromGbbq(xdxrMap, idx, item)
 			}
-		case isShareCategory(item.Category):
+		case model.IsShareCategory(item.Category):
 			sharesList = append(sharesList, item)
 		}
 	}
@@ -260,14 +269,6 @@ func getGbbqBySymbol(index GbbqIndex, symbol string) []model.GbbqData {
 	return []model.GbbqData{}
 }
 
-func isShareCategory(cat int) bool {
-	switch cat {
-	case 2, 3, 5, 7, 8, 9, 10:
-		return true
-	}
-	return false
-}
-
 func mergeXdxrFromGbbq(m map[int]*xdxrInfo, idx int, data model.GbbqData) {
 	if _, ok := m[idx]; !ok {
 		m[idx] = &xdxrInfo{}
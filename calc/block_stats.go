@@ -0,0 +1,182 @@
+package calc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+// blockStatsWindow 是每批从 raw_basic_daily 读取的交易日数，控制单次加载的行数。
+const blockStatsWindow = 20
+
+// ExportBlockStatsToCSV 用当前板块成分与 raw_basic_daily 计算 since 之后 (不含) 每个交易日的
+// 板块统计并导出；since 为零值时计算全部历史。
+func ExportBlockStatsToCSV(
+	ctx context.Context,
+	db database.DataRepository,
+	csvPath string,
+	since time.Time,
+) (int, error) {
+
+	var members []model.BlockMember
+	if err := db.QueryInto(model.RowQuery{Table: model.TableBlockMember.TableName}, &members); err != nil {
+		return 0, fmt.Errorf("failed to query block members: %w", err)
+	}
+	if len(members) == 0 {
+		return 0, nil
+	}
+	blocksOf := make(map[string][]string)
+	for _, m := range members {
+		blocksOf[m.StockSymbol] = append(blocksOf[m.StockSymbol], m.BlockCode)
+	}
+
+	dateQuery := model.RowQuery{
+		Table:   model.TableBasicDaily.TableName,
+		Columns: []string{"DISTINCT date AS date"},
+		OrderBy: []string{"date"},
+	}
+	if !since.IsZero() {
+		dateQuery.Where = []model.Cond{{Column: "date", Op: ">", Value: since}}
+	}
+	var dates []time.Time
+	if err := db.QueryInto(dateQuery, &dates); err != nil {
+		return 0, fmt.Errorf("failed to query basic dates: %w", err)
+	}
+	if len(dates) == 0 {
+		return 0, nil
+	}
+
+	var windows [][]time.Time
+	for start := 0; start < len(dates); start += blockStatsWindow {
+		windows = append(windows, dates[start:min(start+blockStatsWindow, len(dates))])
+	}
+
+	cw, err := utils.NewCSVWriter[model.BlockStatsDaily](csvPath)
+	if err != nil {
+		return 0, err
+	}
+	defer cw.Close()
+
+	log := utils.Logger(ctx)
+	pipeline := utils.NewPipeline[[]time.Time, model.BlockStatsDaily]()
+
+	result, err := pipeline.Run(
+		ctx,
+		windows,
+		func(ctx context.Context, window []time.Time) ([]model.BlockStatsDaily, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+			var basics []model.BasicDaily
+			err := db.QueryInto(model.RowQuery{
+				Table:   model.TableBasicDaily.TableName,
+				Columns: []string{"date", "symbol", "change_pct", "floatmv"},
+				Where: []model.Cond{
+					{Column: "date", Op: ">=", Value: window[0]},
+					{Column: "date", Op: "<=", Value: window[len(window)-1]},
+				},
+			}, &basics)
+			if err != nil {
+				log.Warn("板块统计读取 basic 失败", "from", window[0], "error", err)
+				return nil, err
+			}
+			return CalculateBlockStats(basics, blocksOf), nil
+		},
+		func(rows []model.BlockStatsDaily) error {
+			return cw.Write(rows)
+		},
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	log.Debug("板块统计计算完成", "dates", len(dates),
+		"rows", result.OutputRows, "duration", result.Duration)
+
+	if result.HasErrors() {
+		return 0, fmt.Errorf("export completed with %s", result.ErrorSummary())
+	}
+
+	return int(result.OutputRows), nil
+}
+
+// CalculateBlockStats 按 (板块, 日期) 汇总成分股的涨跌幅，结果按日期、板块代码排序。
+// blocksOf 为 个股 → 所属板块代码，不在其中的 symbol 忽略。
+func CalculateBlockStats(basics []model.BasicDaily, blocksOf map[string][]string) []model.BlockStatsDaily {
+	type key struct {
+		date  time.Time
+		block string
+	}
+	type acc struct {
+		count, up, down   int
+		sumReturn         float64
+		weighted, weights float64
+		floatMV           float64
+	}
+
+	accs := make(map[key]*acc)
+	for _, b := range basics {
+		blocks := blocksOf[b.Symbol]
+		if len(blocks) == 0 {
+			continue
+		}
+		// 以前一日流通市值为权重，避免当日涨跌反过来放大自身权重。
+		var weight float64
+		if b.FloatMV > 0 && b.ChangePercent > -100 {
+			weight = b.FloatMV / (1 + b.ChangePercent/100)
+		}
+		for _, code := range blocks {
+			k := key{date: b.Date, block: code}
+			a := accs[k]
+			if a == nil {
+				a = &acc{}
+				accs[k] = a
+			}
+			a.count++
+			switch {
+			case b.ChangePercent > 0:
+				a.up++
+			case b.ChangePercent < 0:
+				a.down++
+			}
+			a.sumReturn += b.ChangePercent
+			a.weighted += b.ChangePercent * weight
+			a.weights += weight
+			a.floatMV += b.FloatMV
+		}
+	}
+
+	rows := make([]model.BlockStatsDaily, 0, len(accs))
+	for k, a := range accs {
+		row := model.BlockStatsDaily{
+			Date:        k.date,
+			BlockCode:   k.block,
+			MemberCount: a.count,
+			UpCount:     a.up,
+			DownCount:   a.down,
+			EqualReturn: math.Round(a.sumReturn/float64(a.count)*10000) / 10000,
+			FloatMV:     math.Round(a.floatMV*100) / 100,
+		}
+		if a.weights > 0 {
+			v := math.Round(a.weighted/a.weights*10000) / 10000
+			row.FloatCapReturn = &v
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Date.Equal(rows[j].Date) {
+			return rows[i].Date.Before(rows[j].Date)
+		}
+		return rows[i].BlockCode < rows[j].BlockCode
+	})
+	return rows
+}
@@ -0,0 +1,48 @@
+package calc
+
+import (
+	"testing"
+
+	"github.com/jing2uo/tdx2db/model"
+)
+
+func TestCalculateBlockStats(t *testing.T) {
+	blocksOf := map[string][]string{
+		"sz000001": {"X01"},
+		"sh600000": {"X01", "X02"},
+		"sh600036": {"X01"},
+	}
+	d := date(2024, 1, 3)
+	basics := []model.BasicDaily{
+		// 前一日流通市值: 110/1.1 = 100, 90/0.9 = 100, 300/1 = 300
+		{Date: d, Symbol: "sz000001", ChangePercent: 10, FloatMV: 110},
+		{Date: d, Symbol: "sh600000", ChangePercent: -10, FloatMV: 90},
+		{Date: d, Symbol: "sh600036", ChangePercent: 0, FloatMV: 300},
+		{Date: d, Symbol: "sh000001", ChangePercent: 1}, // 非成分
+	}
+
+	rows := CalculateBlockStats(basics, blocksOf)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+	x1 := rows[0]
+	if x1.BlockCode != "X01" || x1.MemberCount != 3 || x1.UpCount != 1 || x1.DownCount != 1 {
+		t.Fatalf("unexpected counts: %+v", x1)
+	}
+	if x1.EqualReturn != 0 || x1.FloatCapReturn == nil || *x1.FloatCapReturn != 0 || x1.FloatMV != 500 {
+		t.Fatalf("unexpected returns: %+v", x1)
+	}
+	x2 := rows[1]
+	if x2.BlockCode != "X02" || x2.EqualReturn != -10 || *x2.FloatCapReturn != -10 {
+		t.Fatalf("unexpected X02: %+v", x2)
+	}
+}
+
+func TestCalculateBlockStatsWithoutFloatMV(t *testing.T) {
+	rows := CalculateBlockStats([]model.BasicDaily{
+		{Date: date(2024, 1, 3), Symbol: "sz000001", ChangePercent: 2},
+	}, map[string][]string{"sz000001": {"X01"}})
+	if len(rows) != 1 || rows[0].EqualReturn != 2 || rows[0].FloatCapReturn != nil {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+}
@@ -21,7 +21,7 @@ func ExportFactorsToCSV(
 	db database.DataRepository,
 	csvPath string,
 ) (int, error) {
-	symbols, err := db.GetSymbolsByClass(model.ClassStock, model.ClassETF)
+	symbols, err := db.GetSymbolsByClass(model.ClassStock, model.ClassBStock, model.ClassETF)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query symbols: %w", err)
 	}
@@ -40,6 +40,7 @@ func ExportFactorsToCSV(
 		DB: db,
 	}
 
+	log := utils.Logger(ctx)
 	pipeline := utils.NewPipeline[string, model.Factor]()
 
 	result, err := pipeline.Run(
@@ -51,7 +52,11 @@ func ExportFactorsToCSV(
 				return nil, ctx.Err()
 			default:
 			}
-			return processFactorSymbol(fctx, symbol)
+			rows, err := processFactorSymbol(fctx, symbol)
+			if err != nil {
+				log.Warn("复权因子计算失败", "symbol", symbol, "error", err)
+			}
+			return rows, err
 		},
 		func(rows []model.Factor) error {
 			return cw.Write(rows)
@@ -62,6 +67,9 @@ func ExportFactorsToCSV(
 		return 0, err
 	}
 
+	log.Debug("复权因子计算完成", "symbols", result.TotalItems,
+		"rows", result.OutputRows, "duration", result.Duration)
+
 	if result.HasErrors() {
 		return 0, fmt.Errorf("export completed with %s", result.ErrorSummary())
 	}
@@ -0,0 +1,290 @@
+package calc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+// AdjustedBar 是某个交易日的后复权开盘 / 收盘价。
+type AdjustedBar struct {
+	Date  time.Time
+	Open  float64
+	Close float64
+}
+
+type ReturnsContext struct {
+	DB        database.DataRepository
+	Days      []time.Time
+	Benchmark string
+	BenchBars []AdjustedBar
+}
+
+// ExportReturnsDailyToCSV 按后复权价格计算股票 / B 股 / ETF 的回看、前瞻与超额收益。
+// days 为升序的交易日序列，收益窗口按其计数，停牌日沿用停牌前收盘价；
+// benchmark 为空或没有日线时超额收益为 NULL。
+func ExportReturnsDailyToCSV(
+	ctx context.Context,
+	db database.DataRepository,
+	csvPath string,
+	benchmark string,
+	days []time.Time,
+) (int, error) {
+
+	symbols, err := db.GetSymbolsByClass(model.ClassStock, model.ClassBStock, model.ClassETF)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query symbols: %w", err)
+	}
+
+	if len(symbols) == 0 || len(days) == 0 {
+		return 0, nil
+	}
+
+	log := utils.Logger(ctx)
+	rc := &ReturnsContext{DB: db, Days: days, Benchmark: benchmark}
+	if benchmark != "" {
+		rc.BenchBars, err = loadAdjustedBars(db, benchmark)
+		if err != nil {
+			return 0, err
+		}
+		if len(rc.BenchBars) == 0 {
+			log.Warn("基准指数没有日线，超额收益为空", "benchmark", benchmark)
+		}
+	}
+
+	cw, err := utils.NewCSVWriter[model.ReturnsDaily](csvPath)
+	if err != nil {
+		return 0, err
+	}
+	defer cw.Close()
+
+	pipeline := utils.NewPipeline[string, model.ReturnsDaily]()
+
+	result, err := pipeline.Run(
+		ctx,
+		symbols,
+		func(ctx context.Context, symbol string) ([]model.ReturnsDaily, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+			bars, err := loadAdjustedBars(rc.DB, symbol)
+			if err != nil {
+				log.Warn("收益率计算失败", "symbol", symbol, "error", err)
+				return nil, err
+			}
+			return CalculateReturnsDaily(symbol, bars, rc.BenchBars, rc.Benchmark, rc.Days), nil
+		},
+		func(rows []model.ReturnsDaily) error {
+			return cw.Write(rows)
+		},
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	log.Debug("收益率计算完成", "symbols", result.TotalItems,
+		"rows", result.OutputRows, "duration", result.Duration)
+
+	if result.HasErrors() {
+		return 0, fmt.Errorf("export completed with %s", result.ErrorSummary())
+	}
+
+	return int(result.OutputRows), nil
+}
+
+// loadAdjustedBars 读取日线并乘以后复权因子；没有因子的日期 (如指数) 按 1 处理，与复权视图一致。
+func loadAdjustedBars(db database.DataRepository, symbol string) ([]AdjustedBar, error) {
+	klines, err := db.QueryKlineDaily(symbol, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("query kline %s failed: %w", symbol, err)
+	}
+	if len(klines) == 0 {
+		return nil, nil
+	}
+
+	var factors []model.Factor
+	err = db.QueryInto(model.RowQuery{
+		Table:   model.TableAdjustFactor.TableName,
+		Where:   []model.Cond{{Column: "symbol", Op: "=", Value: symbol}},
+		OrderBy: []string{"date"},
+	}, &factors)
+	if err != nil {
+		return nil, fmt.Errorf("query factor %s failed: %w", symbol, err)
+	}
+	hfq := make(map[string]float64, len(factors))
+	for _, f := range factors {
+		hfq[dayKey(f.Date)] = f.HfqFactor
+	}
+
+	bars := make([]AdjustedBar, len(klines))
+	for i, k := range klines {
+		factor, ok := hfq[dayKey(k.Date)]
+		if !ok {
+			factor = 1
+		}
+		bars[i] = AdjustedBar{Date: k.Date, Open: k.Open * factor, Close: k.Close * factor}
+	}
+	return bars, nil
+}
+
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// 回看 / 前瞻窗口 (交易日)。
+var (
+	trailingWindows = []int{1, 5, 10, 20, 60}
+	forwardWindows  = []int{1, 5, 20}
+)
+
+// CalculateReturnsDaily 在交易日序列 days 上计算 symbol 每个有行情日期的收益率。
+// 窗口按交易日计数，停牌日价格沿用停牌前收盘价：
+//
+//	ret_N           = close[t] / close[t-N] - 1，t-N 早于首个交易日时为 NULL
+//	fwd_ret_N_open  = open[t+1+N] / open[t+1] - 1，卖出日停牌时取停牌前收盘价
+//	fwd_ret_N_close = close[t+1+N] / close[t+1] - 1
+//
+// 前瞻收益要求 t+1 有行情 (停牌无法买入)，且 t+1+N 不晚于该代码最后一个有行情的交易日，
+// 末尾停牌与退市无法区分，一律为 NULL。超额收益为同窗口收益减去基准收益。
+func CalculateReturnsDaily(symbol string, bars, bench []AdjustedBar, benchmark string, days []time.Time) []model.ReturnsDaily {
+	index := make(map[string]int, len(days))
+	for i, d := range days {
+		index[dayKey(d)] = i
+	}
+	s := newGridSeries(bars, index, len(days))
+	if s.first < 0 {
+		return nil
+	}
+	b := newGridSeries(bench, index, len(days))
+
+	var out []model.ReturnsDaily
+	for i := s.first; i <= s.last; i++ {
+		if !s.traded[i] {
+			continue
+		}
+		row := model.ReturnsDaily{Date: days[i], Symbol: symbol, Benchmark: benchmark}
+
+		trailing := []**float64{&row.Ret1, &row.Ret5, &row.Ret10, &row.Ret20, &row.Ret60}
+		excess := []**float64{&row.ExcessRet1, &row.ExcessRet5, &row.ExcessRet10, &row.ExcessRet20, &row.ExcessRet60}
+		for k, n := range trailingWindows {
+			*trailing[k] = s.change(i-n, i)
+			*excess[k] = diff(*trailing[k], b.change(i-n, i))
+		}
+		if row.Ret1 != nil && *row.Ret1 > -1 {
+			row.LogRet1 = roundReturn(math.Log1p(*row.Ret1))
+		}
+
+		fwdOpen := []**float64{&row.FwdRet1Open, &row.FwdRet5Open, &row.FwdRet20Open}
+		fwdClose := []**float64{&row.FwdRet1Close, &row.FwdRet5Close, &row.FwdRet20Close}
+		fwdExcess := []**float64{&row.FwdExcessRet1Close, &row.FwdExcessRet5Close, &row.FwdExcessRet20Close}
+		for k, n := range forwardWindows {
+			*fwdOpen[k] = s.forwardOpen(i, n)
+			*fwdClose[k] = s.forwardClose(i, n)
+			*fwdExcess[k] = diff(*fwdClose[k], b.forwardClose(i, n))
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+// gridSeries 把一只证券的行情铺到交易日序列上。close 在首末行情之间前向填充，
+// open 只在有行情的日期非零；没有任何行情时 first 为 -1。
+type gridSeries struct {
+	open   []float64
+	close  []float64
+	traded []bool
+	first  int
+	last   int
+}
+
+func newGridSeries(bars []AdjustedBar, index map[string]int, n int) gridSeries {
+	s := gridSeries{first: -1, last: -1}
+	if len(bars) == 0 {
+		return s
+	}
+	s.open = make([]float64, n)
+	s.close = make([]float64, n)
+	s.traded = make([]bool, n)
+	for _, bar := range bars {
+		// 不在交易日序列中的日期 (节假日数据有误) 忽略。
+		i, ok := index[dayKey(bar.Date)]
+		if !ok || bar.Close <= 0 {
+			continue
+		}
+		s.open[i], s.close[i], s.traded[i] = bar.Open, bar.Close, true
+		if s.first < 0 || i < s.first {
+			s.first = i
+		}
+		if i > s.last {
+			s.last = i
+		}
+	}
+	if s.first < 0 {
+		return s
+	}
+	for i := s.first + 1; i <= s.last; i++ {
+		if !s.traded[i] {
+			s.close[i] = s.close[i-1]
+		}
+	}
+	return s
+}
+
+// closeAt 返回第 i 个交易日的收盘价 (停牌日为停牌前收盘价)，超出首末行情时为 false。
+func (s gridSeries) closeAt(i int) (float64, bool) {
+	if s.first < 0 || i < s.first || i > s.last {
+		return 0, false
+	}
+	return s.close[i], true
+}
+
+// change 返回第 from 到第 to 个交易日收盘价的涨幅。
+func (s gridSeries) change(from, to int) *float64 {
+	p0, ok0 := s.closeAt(from)
+	p1, ok1 := s.closeAt(to)
+	if !ok0 || !ok1 {
+		return nil
+	}
+	return roundReturn(p1/p0 - 1)
+}
+
+func (s gridSeries) forwardClose(i, n int) *float64 {
+	entry := i + 1
+	if s.first < 0 || entry > s.last || !s.traded[entry] {
+		return nil
+	}
+	return s.change(entry, entry+n)
+}
+
+func (s gridSeries) forwardOpen(i, n int) *float64 {
+	entry, exit := i+1, i+1+n
+	if s.first < 0 || exit > s.last || !s.traded[entry] || s.open[entry] <= 0 {
+		return nil
+	}
+	price := s.close[exit]
+	if s.traded[exit] && s.open[exit] > 0 {
+		price = s.open[exit]
+	}
+	return roundReturn(price/s.open[entry] - 1)
+}
+
+func diff(a, b *float64) *float64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	return roundReturn(*a - *b)
+}
+
+// roundReturn 保留 6 位小数。
+func roundReturn(v float64) *float64 {
+	r := math.Round(v*1e6) / 1e6
+	return &r
+}
@@ -0,0 +1,74 @@
+package calc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateReturnsDaily(t *testing.T) {
+	days := []time.Time{
+		date(2024, 1, 2), date(2024, 1, 3), date(2024, 1, 4),
+		date(2024, 1, 5), date(2024, 1, 8), date(2024, 1, 9),
+	}
+	// 1/4 停牌。
+	bars := []AdjustedBar{
+		{Date: date(2024, 1, 2), Open: 10, Close: 10},
+		{Date: date(2024, 1, 3), Open: 10.5, Close: 11},
+		{Date: date(2024, 1, 5), Open: 11, Close: 12.1},
+		{Date: date(2024, 1, 8), Open: 12.5, Close: 12},
+		{Date: date(2024, 1, 9), Open: 12, Close: 13},
+	}
+	var bench []AdjustedBar
+	for i, d := range days {
+		bench = append(bench, AdjustedBar{Date: d, Open: 100 + float64(i), Close: 100 + float64(i)})
+	}
+
+	rows := CalculateReturnsDaily("sz000001", bars, bench, "sh000300", days)
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows (no row on suspended day), got %d", len(rows))
+	}
+	eq := func(name string, got *float64, want float64) {
+		t.Helper()
+		if got == nil || *got != want {
+			t.Errorf("%s: want %v, got %v", name, want, got)
+		}
+	}
+	null := func(name string, got *float64) {
+		t.Helper()
+		if got != nil {
+			t.Errorf("%s: want NULL, got %v", name, *got)
+		}
+	}
+
+	first := rows[0]
+	null("first ret_1", first.Ret1)
+	// 买入日 1/3 开盘 10.5，卖出日 1/4 停牌取停牌前收盘 11。
+	eq("first fwd_ret_1_open", first.FwdRet1Open, 0.047619)
+	eq("first fwd_ret_1_close", first.FwdRet1Close, 0)
+	null("first fwd_ret_5_close", first.FwdRet5Close)
+
+	// 下一交易日停牌无法买入。
+	null("before suspension fwd_ret_1_close", rows[1].FwdRet1Close)
+	null("before suspension fwd_ret_1_open", rows[1].FwdRet1Open)
+
+	resume := rows[2]
+	if !resume.Date.Equal(date(2024, 1, 5)) || resume.Benchmark != "sh000300" {
+		t.Fatalf("unexpected row: %+v", resume)
+	}
+	// 回看 1 个交易日落在停牌日，取停牌前收盘 11。
+	eq("resume ret_1", resume.Ret1, 0.1)
+	eq("resume log_ret_1", resume.LogRet1, 0.09531)
+	eq("resume excess_ret_1", resume.ExcessRet1, 0.090196)
+	eq("resume fwd_ret_1_open", resume.FwdRet1Open, -0.04)
+	eq("resume fwd_ret_1_close", resume.FwdRet1Close, 0.083333)
+	eq("resume fwd_excess_ret_1_close", resume.FwdExcessRet1Close, 0.073718)
+
+	// 最后一天之后没有行情。
+	null("tail fwd_ret_1_close", rows[3].FwdRet1Close)
+	eq("last ret_5", rows[4].Ret5, 0.3)
+	null("last ret_10", rows[4].Ret10)
+
+	// 没有基准时超额收益为 NULL。
+	rows = CalculateReturnsDaily("sz000001", bars, nil, "", days)
+	null("no benchmark excess_ret_1", rows[2].ExcessRet1)
+}
@@ -0,0 +1,185 @@
+package calc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+// StitchSource 是拼接序列中的一段：取 Symbol 在 Until 之前 (不含) 的行情，
+// Until 为零值表示最终代码、不设上限。Ratio 为切换到下一段时每股换得的新股数。
+type StitchSource struct {
+	Symbol string
+	Until  time.Time
+	Ratio  float64
+	Basics []model.BasicDaily
+}
+
+// StitchPlan 按 raw_symbol_alias 把代码链 (A → B → C) 展开为最终代码 → 各段来源，
+// 来源按生效日升序，最后一段为最终代码本身；成环的映射忽略。
+func StitchPlan(aliases []model.SymbolAlias) map[string][]StitchSource {
+	next := make(map[string]model.SymbolAlias, len(aliases))
+	for _, a := range aliases {
+		next[a.OldSymbol] = a
+	}
+	final := func(symbol string) (string, bool) {
+		seen := map[string]bool{symbol: true}
+		for {
+			a, ok := next[symbol]
+			if !ok {
+				return symbol, true
+			}
+			symbol = a.NewSymbol
+			if seen[symbol] {
+				return "", false
+			}
+			seen[symbol] = true
+		}
+	}
+
+	plan := make(map[string][]StitchSource)
+	for _, a := range aliases {
+		target, ok := final(a.OldSymbol)
+		if !ok {
+			continue
+		}
+		plan[target] = append(plan[target], StitchSource{Symbol: a.OldSymbol, Until: a.EffectiveDate, Ratio: a.Ratio})
+	}
+	for target, sources := range plan {
+		sort.Slice(sources, func(i, j int) bool { return sources[i].Until.Before(sources[j].Until) })
+		plan[target] = append(sources, StitchSource{Symbol: target, Ratio: 1})
+	}
+	return plan
+}
+
+// ExportStitchedDailyToCSV 按 raw_symbol_alias 拼接新旧代码的 basic 并重算后复权因子。
+func ExportStitchedDailyToCSV(
+	ctx context.Context,
+	db database.DataRepository,
+	csvPath string,
+) (int, error) {
+
+	var aliases []model.SymbolAlias
+	if err := db.QueryInto(model.RowQuery{Table: model.TableSymbolAlias.TableName}, &aliases); err != nil {
+		return 0, fmt.Errorf("failed to query symbol aliases: %w", err)
+	}
+	plan := StitchPlan(aliases)
+	if len(plan) == 0 {
+		return 0, nil
+	}
+	targets := make([]string, 0, len(plan))
+	for target := range plan {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	cw, err := utils.NewCSVWriter[model.StitchedDaily](csvPath)
+	if err != nil {
+		return 0, err
+	}
+	defer cw.Close()
+
+	log := utils.Logger(ctx)
+	pipeline := utils.NewPipeline[string, model.StitchedDaily]()
+
+	result, err := pipeline.Run(
+		ctx,
+		targets,
+		func(ctx context.Context, target string) ([]model.StitchedDaily, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+			sources := make([]StitchSource, len(plan[target]))
+			copy(sources, plan[target])
+			for i := range sources {
+				basics, err := db.GetBasicsBySymbol(sources[i].Symbol)
+				if err != nil {
+					log.Warn("拼接序列读取 basic 失败", "symbol", sources[i].Symbol, "error", err)
+					return nil, err
+				}
+				sources[i].Basics = basics
+			}
+			return CalculateStitchedDaily(target, sources), nil
+		},
+		func(rows []model.StitchedDaily) error {
+			return cw.Write(rows)
+		},
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	log.Debug("拼接序列计算完成", "symbols", result.TotalItems,
+		"rows", result.OutputRows, "duration", result.Duration)
+
+	if result.HasErrors() {
+		return 0, fmt.Errorf("export completed with %s", result.ErrorSummary())
+	}
+
+	return int(result.OutputRows), nil
+}
+
+// CalculateStitchedDaily 按顺序拼接各段 basic 并在整条序列上重算后复权因子。
+// 每段只取晚于上一段末日的行；切换日的前收取上一段最后收盘价 / Ratio，
+// 涨跌幅与振幅随之重算，因此代码切换本身不产生除权缺口。
+func CalculateStitchedDaily(symbol string, sources []StitchSource) []model.StitchedDaily {
+	var rows []model.BasicDaily
+	var origins []string
+	var last time.Time
+	var prevClose float64
+	joinRatio := 1.0
+
+	for _, src := range sources {
+		added := false
+		for _, b := range src.Basics {
+			if !src.Until.IsZero() && !b.Date.Before(src.Until) {
+				continue
+			}
+			if len(rows) > 0 && !b.Date.After(last) {
+				continue
+			}
+			if len(rows) > 0 && !added && prevClose > 0 && joinRatio > 0 {
+				pre := prevClose / joinRatio
+				if b.PreClose > 0 {
+					b.Amplitude = math.Round(b.Amplitude*b.PreClose/pre*100) / 100
+				}
+				b.PreClose = pre
+				b.ChangePercent = math.Round((b.Close-pre)/pre*100*100) / 100
+			}
+			b.Symbol = symbol
+			rows = append(rows, b)
+			origins = append(origins, src.Symbol)
+			last, prevClose, added = b.Date, b.Close, true
+		}
+		// 中间某段没有行情时，把它的换股比例并入下一次切换。
+		if added {
+			joinRatio = src.Ratio
+		} else {
+			joinRatio *= src.Ratio
+		}
+	}
+
+	factors := calculateFullHfq(rows)
+	out := make([]model.StitchedDaily, len(rows))
+	for i, b := range rows {
+		out[i] = model.StitchedDaily{
+			Symbol:        symbol,
+			Date:          b.Date,
+			SourceSymbol:  origins[i],
+			PreClose:      b.PreClose,
+			ChangePercent: b.ChangePercent,
+			Amplitude:     b.Amplitude,
+			HfqFactor:     factors[i].HfqFactor,
+		}
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package calc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jing2uo/tdx2db/model"
+)
+
+func TestStitchPlan(t *testing.T) {
+	aliases := []model.SymbolAlias{
+		{OldSymbol: "bj830002", NewSymbol: "bj830003", EffectiveDate: date(2024, 6, 3), Ratio: 1},
+		{OldSymbol: "bj830001", NewSymbol: "bj830002", EffectiveDate: date(2024, 3, 1), Ratio: 0.5},
+		// 成环的映射忽略。
+		{OldSymbol: "sh600001", NewSymbol: "sh600002", EffectiveDate: date(2024, 3, 1), Ratio: 1},
+		{OldSymbol: "sh600002", NewSymbol: "sh600001", EffectiveDate: date(2024, 4, 1), Ratio: 1},
+	}
+
+	plan := StitchPlan(aliases)
+	if len(plan) != 1 {
+		t.Fatalf("expected 1 target, got %+v", plan)
+	}
+	sources := plan["bj830003"]
+	if len(sources) != 3 || sources[0].Symbol != "bj830001" || sources[0].Ratio != 0.5 ||
+		sources[1].Symbol != "bj830002" || sources[2].Symbol != "bj830003" || !sources[2].Until.IsZero() {
+		t.Fatalf("unexpected sources: %+v", sources)
+	}
+}
+
+func TestCalculateStitchedDaily(t *testing.T) {
+	oldBasics := []model.BasicDaily{
+		{Date: date(2024, 1, 2), Symbol: "bj830799", Close: 10, PreClose: 10},
+		{Date: date(2024, 1, 3), Symbol: "bj830799", Close: 11, PreClose: 10, ChangePercent: 10},
+	}
+	newBasics := []model.BasicDaily{
+		// 新代码首日前收为自身收盘价；按前收 11 重算振幅为 8.7 * 11.5 / 11
+		{Date: date(2024, 1, 4), Symbol: "bj920799", Close: 11.5, PreClose: 11.5, Amplitude: 8.7},
+		// 除权：前收 10 低于上一日收盘 11.5
+		{Date: date(2024, 1, 5), Symbol: "bj920799", Close: 10, PreClose: 10},
+	}
+	sources := []StitchSource{
+		{Symbol: "bj830799", Until: date(2024, 1, 4), Ratio: 1, Basics: oldBasics},
+		{Symbol: "bj920799", Ratio: 1, Basics: newBasics},
+	}
+
+	rows := CalculateStitchedDaily("bj920799", sources)
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %+v", rows)
+	}
+	if rows[0].SourceSymbol != "bj830799" || rows[0].Symbol != "bj920799" || rows[2].SourceSymbol != "bj920799" {
+		t.Fatalf("unexpected sources: %+v", rows)
+	}
+	join := rows[2]
+	if join.PreClose != 11 || join.ChangePercent != 4.55 || join.Amplitude != 9.1 || join.HfqFactor != 1 {
+		t.Fatalf("unexpected join day: %+v", join)
+	}
+	if math.Abs(rows[3].HfqFactor-1.15) > 1e-9 {
+		t.Fatalf("expected ex-rights factor 1.15, got %v", rows[3].HfqFactor)
+	}
+
+	// 每股旧股换 0.5 股新股：切换日前收翻倍，因子减半。
+	sources[0].Ratio = 0.5
+	rows = CalculateStitchedDaily("bj920799", sources)
+	if rows[2].PreClose != 22 || rows[2].HfqFactor != 0.5 {
+		t.Fatalf("unexpected join day with ratio: %+v", rows[2])
+	}
+}
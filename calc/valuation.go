@@ -0,0 +1,278 @@
+package calc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+// FinanceReport 是估值计算用到的一期财报字段。利润表字段为报告期内累计值 (YTD)，
+// 缺失时为 nil。
+type FinanceReport struct {
+	ReportDate   time.Time  `col:"report_date"`
+	AnnounceDate *time.Time `col:"announce_date"`
+	NetProfit    *float64   `col:"net_profit"` // 归母净利润
+	Revenue      *float64   `col:"revenue"`    // 营业收入
+	Equity       *float64   `col:"equity"`     // 归母所有者权益
+}
+
+// financeReportColumns 从 raw_finance 取出 FinanceReport 的列；
+// 归母权益 = 所有者权益合计 (f072) - 少数股东权益 (f069)。
+var financeReportColumns = []string{
+	"report_date",
+	"announce_date",
+	model.FinanceFieldColumn(96) + " AS net_profit",
+	model.FinanceFieldColumn(74) + " AS revenue",
+	fmt.Sprintf("%s - COALESCE(%s, 0) AS equity", model.FinanceFieldColumn(72), model.FinanceFieldColumn(69)),
+}
+
+// AvailableDate 返回财报可被使用的日期：优先取公告日，未知时按法定披露截止日
+// (一季报 4/30、半年报 8/31、三季报 10/31、年报次年 4/30) 保守估计，避免未来函数。
+func (r FinanceReport) AvailableDate() time.Time {
+	if r.AnnounceDate != nil && !r.AnnounceDate.IsZero() {
+		return *r.AnnounceDate
+	}
+	y := r.ReportDate.Year()
+	switch r.ReportDate.Month() {
+	case time.March:
+		return time.Date(y, time.April, 30, 0, 0, 0, 0, time.UTC)
+	case time.June:
+		return time.Date(y, time.August, 31, 0, 0, 0, 0, time.UTC)
+	case time.September:
+		return time.Date(y, time.October, 31, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y+1, time.April, 30, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+type ValuationContext struct {
+	DB        database.DataRepository
+	GbbqIndex GbbqIndex
+}
+
+// ExportValuationDailyToCSV 计算并导出个股每日估值 (PE/PB/PS/股息率)。
+func ExportValuationDailyToCSV(
+	ctx context.Context,
+	db database.DataRepository,
+	csvPath string,
+) (int, error) {
+
+	gbbqData, err := db.GetGbbq()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query gbbq: %w", err)
+	}
+
+	symbols, err := db.GetSymbolsByClass(model.ClassStock)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query symbols: %w", err)
+	}
+
+	cw, err := utils.NewCSVWriter[model.ValuationDaily](csvPath)
+	if err != nil {
+		return 0, err
+	}
+	defer cw.Close()
+
+	valCtx := &ValuationContext{
+		DB:        db,
+		GbbqIndex: buildGbbqIndex(gbbqData),
+	}
+
+	log := utils.Logger(ctx)
+	pipeline := utils.NewPipeline[string, model.ValuationDaily]()
+
+	result, err := pipeline.Run(
+		ctx,
+		symbols,
+		func(ctx context.Context, symbol string) ([]model.ValuationDaily, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+			rows, err := processValuation(valCtx, symbol)
+			if err != nil {
+				log.Warn("估值计算失败", "symbol", symbol, "error", err)
+			}
+			return rows, err
+		},
+		func(rows []model.ValuationDaily) error {
+			return cw.Write(rows)
+		},
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	log.Debug("估值计算完成", "symbols", result.TotalItems,
+		"rows", result.OutputRows, "duration", result.Duration)
+
+	if result.HasErrors() {
+		return 0, fmt.Errorf("export completed with %s", result.ErrorSummary())
+	}
+
+	return int(result.OutputRows), nil
+}
+
+func processValuation(vc *ValuationContext, symbol string) ([]model.ValuationDaily, error) {
+	basics, err := vc.DB.GetBasicsBySymbol(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("query basic %s failed: %w", symbol, err)
+	}
+	if len(basics) == 0 {
+		return nil, nil
+	}
+
+	var reports []FinanceReport
+	err = vc.DB.QueryInto(model.RowQuery{
+		Table:   model.TableFinance.TableName,
+		Columns: financeReportColumns,
+		Where:   []model.Cond{{Column: "symbol", Op: "=", Value: symbol}},
+		OrderBy: []string{"report_date"},
+	}, &reports)
+	if err != nil {
+		return nil, fmt.Errorf("query finance %s failed: %w", symbol, err)
+	}
+
+	return CalculateValuationDaily(basics, reports, getGbbqBySymbol(vc.GbbqIndex, symbol)), nil
+}
+
+// CalculateValuationDaily 按交易日计算估值。每个交易日只使用当日已可用 (AvailableDate <= date)
+// 的财报：
+//
+//	PE(TTM)  = 总市值 / 最近四个季度归母净利润
+//	PE(静)   = 总市值 / 最近一期年报归母净利润
+//	PB       = 总市值 / 最新一期归母权益
+//	PS(TTM)  = 总市值 / 最近四个季度营业收入
+//	股息率   = 过去一年 (除权日在 (date-1y, date]) 每股现金分红之和 / 收盘价 * 100
+//
+// TTM = 本期累计 + 上年年报 - 上年同期累计；本期为年报时直接取年报。
+func CalculateValuationDaily(
+	basics []model.BasicDaily,
+	reports []FinanceReport,
+	gbbqData []model.GbbqData,
+) []model.ValuationDaily {
+
+	if len(basics) == 0 {
+		return nil
+	}
+
+	pending := make([]FinanceReport, len(reports))
+	copy(pending, reports)
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].AvailableDate().Before(pending[j].AvailableDate())
+	})
+
+	// cat=1 C1 为每 10 股派现 (元)，同一除权日可能有多条。
+	type dividend struct {
+		date     time.Time
+		perShare float64
+	}
+	var dividends []dividend
+	for _, g := range gbbqData {
+		if g.Category == 1 && g.C1 > 0 {
+			dividends = append(dividends, dividend{date: g.Date, perShare: g.C1 / 10})
+		}
+	}
+	sort.Slice(dividends, func(i, j int) bool { return dividends[i].date.Before(dividends[j].date) })
+
+	known := make(map[time.Time]FinanceReport)
+	var latest, latestAnnual time.Time
+	next := 0
+
+	var profitTTM, revenueTTM, profitStatic, equity *float64
+
+	divHead, divTail := 0, 0
+	var divSum float64
+
+	results := make([]model.ValuationDaily, len(basics))
+	for i, b := range basics {
+		changed := false
+		for next < len(pending) && !pending[next].AvailableDate().After(b.Date) {
+			r := pending[next]
+			rd := reportKey(r.ReportDate)
+			known[rd] = r
+			if rd.After(latest) {
+				latest = rd
+			}
+			if rd.Month() == time.December && rd.After(latestAnnual) {
+				latestAnnual = rd
+			}
+			next++
+			changed = true
+		}
+		if changed {
+			profitTTM = trailingTwelveMonths(known, latest, func(r FinanceReport) *float64 { return r.NetProfit })
+			revenueTTM = trailingTwelveMonths(known, latest, func(r FinanceReport) *float64 { return r.Revenue })
+			profitStatic = nil
+			if r, ok := known[latestAnnual]; ok {
+				profitStatic = r.NetProfit
+			}
+			equity = known[latest].Equity
+		}
+
+		for divTail < len(dividends) && !dividends[divTail].date.After(b.Date) {
+			divSum += dividends[divTail].perShare
+			divTail++
+		}
+		yearAgo := b.Date.AddDate(-1, 0, 0)
+		for divHead < divTail && !dividends[divHead].date.After(yearAgo) {
+			divSum -= dividends[divHead].perShare
+			divHead++
+		}
+
+		v := model.ValuationDaily{Date: b.Date, Symbol: b.Symbol, TotalMV: b.TotalMV}
+		if b.TotalMV > 0 {
+			v.PETTM = valuationRatio(b.TotalMV, profitTTM, false)
+			v.PEStatic = valuationRatio(b.TotalMV, profitStatic, false)
+			v.PB = valuationRatio(b.TotalMV, equity, true)
+			v.PSTTM = valuationRatio(b.TotalMV, revenueTTM, true)
+		}
+		if b.Close > 0 && divTail > divHead {
+			v.DividendYieldTTM = math.Round(divSum/b.Close*100*10000) / 10000
+		}
+		results[i] = v
+	}
+
+	return results
+}
+
+// reportKey 把报告期规整为 UTC 零点，避免不同 driver 返回的时区影响 map 查找。
+func reportKey(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// trailingTwelveMonths 计算截至报告期 at 的最近四个季度累计值，缺少任一所需报告期时返回 nil。
+func trailingTwelveMonths(known map[time.Time]FinanceReport, at time.Time, pick func(FinanceReport) *float64) *float64 {
+	cur, ok := known[at]
+	if !ok || pick(cur) == nil {
+		return nil
+	}
+	if at.Month() == time.December {
+		return pick(cur)
+	}
+	lastAnnual, ok1 := known[time.Date(at.Year()-1, time.December, 31, 0, 0, 0, 0, time.UTC)]
+	lastSame, ok2 := known[time.Date(at.Year()-1, at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)]
+	if !ok1 || !ok2 || pick(lastAnnual) == nil || pick(lastSame) == nil {
+		return nil
+	}
+	v := *pick(cur) + *pick(lastAnnual) - *pick(lastSame)
+	return &v
+}
+
+// valuationRatio 返回 mv / denom 并保留 4 位小数；分母缺失或为 0 时返回 nil，
+// positive 为 true 时分母非正也返回 nil (PB / PS 不取负值)。
+func valuationRatio(mv float64, denom *float64, positive bool) *float64 {
+	if denom == nil || *denom == 0 || (positive && *denom < 0) {
+		return nil
+	}
+	v := math.Round(mv / *denom * 10000) / 10000
+	return &v
+}
@@ -0,0 +1,87 @@
+package calc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jing2uo/tdx2db/model"
+)
+
+func fptr(v float64) *float64 { return &v }
+
+func tptr(t time.Time) *time.Time { return &t }
+
+func TestFinanceReportAvailableDate(t *testing.T) {
+	announced := FinanceReport{ReportDate: date(2023, 6, 30), AnnounceDate: tptr(date(2023, 8, 20))}
+	if got := announced.AvailableDate(); !got.Equal(date(2023, 8, 20)) {
+		t.Fatalf("announced report available %v", got)
+	}
+	annual := FinanceReport{ReportDate: date(2023, 12, 31)}
+	if got := annual.AvailableDate(); !got.Equal(date(2024, 4, 30)) {
+		t.Fatalf("annual report deadline %v", got)
+	}
+}
+
+// TestValuationPointInTime 验证估值只使用公告日之前已披露的财报，且 TTM 按
+// 本期累计 + 上年年报 - 上年同期累计 计算。
+func TestValuationPointInTime(t *testing.T) {
+	reports := []FinanceReport{
+		{ReportDate: date(2022, 6, 30), AnnounceDate: tptr(date(2022, 8, 25)), NetProfit: fptr(40), Revenue: fptr(400), Equity: fptr(900)},
+		{ReportDate: date(2022, 12, 31), AnnounceDate: tptr(date(2023, 3, 30)), NetProfit: fptr(100), Revenue: fptr(1000), Equity: fptr(1000)},
+		{ReportDate: date(2023, 6, 30), AnnounceDate: tptr(date(2023, 8, 25)), NetProfit: fptr(60), Revenue: fptr(500), Equity: fptr(1100)},
+	}
+	basics := []model.BasicDaily{
+		{Date: date(2023, 3, 29), Symbol: "sz000001", Close: 10, TotalMV: 2000},
+		{Date: date(2023, 3, 30), Symbol: "sz000001", Close: 10, TotalMV: 2000},
+		{Date: date(2023, 8, 25), Symbol: "sz000001", Close: 10, TotalMV: 2400},
+	}
+
+	rows := CalculateValuationDaily(basics, reports, nil)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+
+	// 3/29 年报未公告，只有半年报，缺上年数据无法算 TTM
+	if rows[0].PETTM != nil || rows[0].PEStatic != nil {
+		t.Fatalf("expected NULL PE before annual report, got %+v", rows[0])
+	}
+	if rows[0].PB == nil || *rows[0].PB != 2.2222 {
+		t.Fatalf("unexpected PB on 3/29: %v", rows[0].PB)
+	}
+
+	if rows[1].PETTM == nil || *rows[1].PETTM != 20 || *rows[1].PEStatic != 20 {
+		t.Fatalf("unexpected PE on 3/30: %+v", rows[1])
+	}
+
+	// TTM 净利润 = 60 + 100 - 40 = 120；营收 = 500 + 1000 - 400 = 1100
+	if *rows[2].PETTM != 20 || *rows[2].PEStatic != 24 {
+		t.Fatalf("unexpected PE on 8/25: ttm=%v static=%v", *rows[2].PETTM, *rows[2].PEStatic)
+	}
+	if *rows[2].PSTTM != 2.1818 || *rows[2].PB != 2.1818 {
+		t.Fatalf("unexpected PS/PB on 8/25: ps=%v pb=%v", *rows[2].PSTTM, *rows[2].PB)
+	}
+}
+
+func TestValuationDividendYield(t *testing.T) {
+	gbbq := []model.GbbqData{
+		{Category: 1, Symbol: "sh600000", Date: date(2022, 7, 1), C1: 3},
+		{Category: 1, Symbol: "sh600000", Date: date(2023, 6, 1), C1: 5},
+		{Category: 1, Symbol: "sh600000", Date: date(2023, 6, 1), C3: 2}, // 仅送股
+	}
+	basics := []model.BasicDaily{
+		{Date: date(2022, 6, 30), Symbol: "sh600000", Close: 10},
+		{Date: date(2023, 6, 1), Symbol: "sh600000", Close: 10},
+		{Date: date(2023, 7, 3), Symbol: "sh600000", Close: 10},
+	}
+
+	rows := CalculateValuationDaily(basics, nil, gbbq)
+	want := []float64{0, 8, 5}
+	for i, w := range want {
+		if rows[i].DividendYieldTTM != w {
+			t.Fatalf("row %d: expected dv_ttm %v, got %v", i, w, rows[i].DividendYieldTTM)
+		}
+	}
+	if rows[1].PETTM != nil {
+		t.Fatalf("expected NULL PE without finance data")
+	}
+}
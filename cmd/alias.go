@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+// Alias 打印 raw_symbol_alias；file 非空时先把文件中的映射合并进表 (覆盖同一旧代码的已有映射)，
+// 再重算拼接序列。
+func Alias(ctx context.Context, dbURI, file string) error {
+	var imported []model.SymbolAlias
+	if file != "" {
+		var err error
+		if imported, err = model.LoadSymbolAliases(file); err != nil {
+			return err
+		}
+	}
+
+	db, err := database.NewDB(dbURI)
+	if err != nil {
+		return fmt.Errorf("failed to create database driver: %w", err)
+	}
+
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	if err := checkSchemaVersion(db); err != nil {
+		return err
+	}
+
+	var aliases []model.SymbolAlias
+	if err := db.QueryInto(model.RowQuery{Table: model.TableSymbolAlias.TableName}, &aliases); err != nil {
+		return fmt.Errorf("failed to query symbol aliases: %w", err)
+	}
+
+	if file != "" {
+		aliases = model.MergeSymbolAliases(aliases, imported, true)
+		csvPath := filepath.Join(TempDir, "symbol_alias.csv")
+		writer, err := utils.NewCSVWriter[model.SymbolAlias](csvPath)
+		if err != nil {
+			return fmt.Errorf("failed to create symbol alias CSV writer: %w", err)
+		}
+		if err := writer.Write(aliases); err != nil {
+			writer.Close()
+			return err
+		}
+		writer.Close()
+
+		db.TruncateTable(model.TableSymbolAlias)
+		if err := db.ImportCSV(model.TableSymbolAlias, csvPath); err != nil {
+			return fmt.Errorf("failed to import symbol aliases: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rows, err := workflow.RebuildStitchedDaily(ctx, db, TempDir)
+		if err != nil {
+			return err
+		}
+		slog.Info(fmt.Sprintf("🔗 导入 %d 条代码变更映射", len(imported)),
+			"imported", len(imported), "aliases", len(aliases), "stitched_rows", rows)
+	}
+
+	fmt.Println("old_symbol  new_symbol  effective   ratio  source")
+	for _, a := range aliases {
+		fmt.Printf("%-11s %-11s %s  %-6g %s\n",
+			a.OldSymbol, a.NewSymbol, a.EffectiveDate.Format("2006-01-02"), a.Ratio, a.Source)
+	}
+	return nil
+}
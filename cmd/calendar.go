@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+// CalendarOptions 对应 calendar 子命令的三种查询，必须且只能指定一种。
+type CalendarOptions struct {
+	Next      int      // 今天之后的 N 个交易日
+	Between   []string // [start, end] 内的交易日
+	IsTrading string   // 判断某天是否为交易日
+}
+
+// Calendar 按 raw_holidays 构造交易日历并打印查询结果，每行一个日期。
+func Calendar(ctx context.Context, dbURI string, opts CalendarOptions) error {
+	modes := 0
+	for _, set := range []bool{opts.Next > 0, len(opts.Between) > 0, opts.IsTrading != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return fmt.Errorf("specify exactly one of --next, --between, --is-trading")
+	}
+
+	var start, end, day time.Time
+	var err error
+	if len(opts.Between) > 0 {
+		if len(opts.Between) != 2 {
+			return fmt.Errorf("--between requires two dates, got %v", opts.Between)
+		}
+		if start, err = parseCalendarDate(opts.Between[0]); err != nil {
+			return err
+		}
+		if end, err = parseCalendarDate(opts.Between[1]); err != nil {
+			return err
+		}
+		if end.Before(start) {
+			return fmt.Errorf("end %s is before start %s", opts.Between[1], opts.Between[0])
+		}
+	}
+	if opts.IsTrading != "" {
+		if day, err = parseCalendarDate(opts.IsTrading); err != nil {
+			return err
+		}
+	}
+
+	db, err := database.NewDB(dbURI)
+	if err != nil {
+		return fmt.Errorf("failed to create database driver: %w", err)
+	}
+
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := checkSchemaVersion(db); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	holidays, err := db.GetHolidays()
+	if err != nil {
+		return fmt.Errorf("failed to load holidays: %w", err)
+	}
+	if len(holidays) == 0 {
+		return fmt.Errorf("raw_holidays is empty, run cron first")
+	}
+	cal := workflow.NewTradingCalendar(holidays)
+
+	switch {
+	case opts.Next > 0:
+		printDates(cal.NextTradingDays(GetToday(), opts.Next))
+	case len(opts.Between) > 0:
+		printDates(cal.TradingDays(start, end))
+	default:
+		fmt.Printf("%s %v\n", day.Format("2006-01-02"), cal.IsTradingDay(day))
+	}
+	return nil
+}
+
+func parseCalendarDate(s string) (time.Time, error) {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD", s)
+	}
+	return d, nil
+}
+
+func printDates(days []time.Time) {
+	for _, d := range days {
+		fmt.Println(d.Format("2006-01-02"))
+	}
+}
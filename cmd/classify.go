@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+)
+
+// ClassChange 是 rebuild 前后分类不同的代码；新出现或消失的代码对应一侧为空。
+type ClassChange struct {
+	Symbol string
+	Old    string
+	New    string
+}
+
+// Classify 打印当前生效的分类规则；rebuild 为 true 时按规则重建 raw_symbol_class
+// 并列出分类发生变化的代码。
+func Classify(ctx context.Context, dbURI string, rebuild bool) error {
+	if !rebuild {
+		printClassRules()
+		return nil
+	}
+
+	db, err := database.NewDB(dbURI)
+	if err != nil {
+		return fmt.Errorf("failed to create database driver: %w", err)
+	}
+
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.InitSchema(); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	if err := checkSchemaVersion(db); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	before, err := loadSymbolClasses(db)
+	if err != nil {
+		return err
+	}
+	if err := db.RebuildSymbolClass(); err != nil {
+		return fmt.Errorf("failed to rebuild symbol class: %w", err)
+	}
+	after, err := loadSymbolClasses(db)
+	if err != nil {
+		return err
+	}
+
+	changes := diffSymbolClasses(before, after)
+	for _, c := range changes {
+		fmt.Printf("  %-10s %-8s → %s\n", c.Symbol, displayClass(c.Old), displayClass(c.New))
+	}
+	slog.Info(fmt.Sprintf("🧭 品种分类已重建，%d 个代码分类变化", len(changes)),
+		"symbols", len(after), "changed", len(changes))
+	return nil
+}
+
+func loadSymbolClasses(db database.DataRepository) (map[string]string, error) {
+	var rows []model.SymbolClass
+	if err := db.QueryInto(model.RowQuery{Table: model.TableSymbolClass.TableName}, &rows); err != nil {
+		return nil, fmt.Errorf("failed to load symbol class: %w", err)
+	}
+	classes := make(map[string]string, len(rows))
+	for _, r := range rows {
+		classes[r.Symbol] = r.Class
+	}
+	return classes, nil
+}
+
+// diffSymbolClasses 返回按代码排序的分类变化。
+func diffSymbolClasses(before, after map[string]string) []ClassChange {
+	var changes []ClassChange
+	for symbol, class := range after {
+		if before[symbol] != class {
+			changes = append(changes, ClassChange{Symbol: symbol, Old: before[symbol], New: class})
+		}
+	}
+	for symbol, class := range before {
+		if _, ok := after[symbol]; !ok {
+			changes = append(changes, ClassChange{Symbol: symbol, Old: class})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Symbol < changes[j].Symbol })
+	return changes
+}
+
+func displayClass(class string) string {
+	if class == "" {
+		return "-"
+	}
+	return class
+}
+
+func printClassRules() {
+	rules := model.ClassRules()
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Market != rules[j].Market {
+			return rules[i].Market < rules[j].Market
+		}
+		return rules[i].Prefix < rules[j].Prefix
+	})
+	fmt.Println("market  prefix  class    scale")
+	for _, r := range rules {
+		scale := "-"
+		if r.Scale > 0 {
+			scale = fmt.Sprint(r.Scale)
+		}
+		fmt.Printf("%-7s %-7s %-8s %s\n", r.Market, r.Prefix, r.Class, scale)
+	}
+}
@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/tdx"
 	"github.com/jing2uo/tdx2db/utils"
 )
 
@@ -51,3 +54,48 @@ func OverrideTempDir(parent string) error {
 	return nil
 }
 
+// OverrideTDXHosts 用 --tdx-hosts / --tdx-mac-hosts 覆盖内置的通达信主站列表,
+// 留空的一侧保持内置列表。
+func OverrideTDXHosts(standard, mac []string) error {
+	std, err := tdx.ParseHosts(standard)
+	if err != nil {
+		return err
+	}
+	m, err := tdx.ParseHosts(mac)
+	if err != nil {
+		return err
+	}
+	tdx.SetHosts(m, std)
+	return nil
+}
+
+// OverrideClassRules 把 --class-rules 指定的规则文件合并到内置分类规则上，留空不改动。
+func OverrideClassRules(path string) error {
+	if path == "" {
+		return nil
+	}
+	rules, err := model.LoadClassRules(path)
+	if err != nil {
+		return err
+	}
+	model.SetClassRules(rules)
+	return nil
+}
+
+// SetupLogger 按 --log-level / --log-format 构造全局 logger 并设为 slog 默认值,
+// 之后 cmd / workflow / tdx / calc 的输出都经由它。
+func SetupLogger(level, format string) error {
+	logger, err := utils.NewLogger(level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// closeOnlineHooks 在命令退出前关闭在线协议录制文件 / 回放服务器，失败只记日志。
+func closeOnlineHooks() {
+	if err := tdx.CloseOnlineHooks(); err != nil {
+		slog.Warn("⚠️ 关闭在线协议录制文件失败", "error", err)
+	}
+}
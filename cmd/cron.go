@@ -3,12 +3,43 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/metrics"
+	"github.com/jing2uo/tdx2db/notify"
 	"github.com/jing2uo/tdx2db/workflow"
 )
 
-func Cron(ctx context.Context, dbURI string, min bool) error {
+// CronOptions 汇总 cron 子命令的开关。
+type CronOptions struct {
+	Min    bool // 导入 1 分钟分时
+	DryRun bool // 只打印计划与远端探测结果，不写库、不下载
+	// OnlineFill 在 g4day 缺失 / 未发布时用在线行情补齐日线，官方数据到达后自动覆盖。
+	OnlineFill bool
+	// Quotes 在运行末尾抓取一次实时行情快照写入 raw_quote_snapshot。
+	Quotes bool
+	// Benchmark 是 raw_returns_daily 超额收益的基准指数代码，留空不算超额收益。
+	Benchmark string
+	// MetricsTextfile 非空时，运行结束后把指标写到该路径 (node_exporter textfile collector)。
+	MetricsTextfile string
+	// Webhooks 形如 "wecom=https://..."，每次运行结束后推送摘要，见 notify.ParseTarget。
+	Webhooks []string
+}
+
+func Cron(ctx context.Context, dbURI string, opts CronOptions) error {
+	started := time.Now()
+	targets := make([]notify.Target, 0, len(opts.Webhooks))
+	for _, spec := range opts.Webhooks {
+		t, err := notify.ParseTarget(spec)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, t)
+	}
+
 	db, err := database.NewDB(dbURI)
 	if err != nil {
 		return fmt.Errorf("failed to create database driver: %w", err)
@@ -18,8 +49,11 @@ func Cron(ctx context.Context, dbURI string, min bool) error {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	if err := db.InitSchema(); err != nil {
-		return fmt.Errorf("failed to initialize schema: %w", err)
+	// dry-run 不建表，库结构缺失时由 checkSchemaVersion 报错即可。
+	if !opts.DryRun {
+		if err := db.InitSchema(); err != nil {
+			return fmt.Errorf("failed to initialize schema: %w", err)
+		}
 	}
 
 	if err := checkSchemaVersion(db); err != nil {
@@ -27,6 +61,7 @@ func Cron(ctx context.Context, dbURI string, min bool) error {
 	}
 
 	defer db.Close()
+	defer closeOnlineHooks()
 
 	if err := ctx.Err(); err != nil {
 		return err
@@ -38,29 +73,152 @@ func Cron(ctx context.Context, dbURI string, min bool) error {
 	if err != nil {
 		return err
 	}
+
+	executor := workflow.NewTaskExecutor(db, workflow.GetRegisteredTasks())
+
+	args := &workflow.TaskArgs{
+		Min:        opts.Min,
+		OnlineFill: opts.OnlineFill,
+		Quotes:     opts.Quotes,
+		Benchmark:  opts.Benchmark,
+		TempDir:    TempDir,
+		VipdocDir:  VipdocDir,
+		Today:      today,
+		Plan:       plan,
+		Logger:     slog.Default(),
+	}
+
+	taskNames := workflow.GetUpdateTaskNames()
+
+	if opts.DryRun {
+		return dryRun(ctx, db, executor, taskNames, args)
+	}
+
 	if plan.Reason != "" {
-		fmt.Println(plan.Reason)
+		slog.Info(plan.Reason,
+			"need_daily", plan.NeedDaily,
+			"need_gbbq", plan.NeedGbbq,
+			"need_basic", plan.NeedBasic,
+			"need_factor", plan.NeedFactor,
+			"need_valuation", plan.NeedValuation,
+			"need_block_stats", plan.NeedBlockStats,
+			"need_holidays", plan.NeedHolidays,
+		)
 	}
 	if !plan.AnyNeeded() {
+		// 盘中定时抓行情时日线等均无需更新，只跑快照任务。
+		var runErr error
+		if opts.Quotes {
+			runErr = executor.Run(ctx, []string{workflow.TaskSnapshotQuotes.Name}, args)
+		}
+		finishRun(ctx, db, executor, plan, opts, targets, runErr, started)
+		return runErr
+	}
+
+	runErr := executor.Run(ctx, taskNames, args)
+	finishRun(ctx, db, executor, plan, opts, targets, runErr, started)
+	if runErr != nil {
+		return fmt.Errorf("workflow execution failed: %w", runErr)
+	}
+
+	if workflow.HasFailures(executor.Report()) {
+		slog.Warn("⚠️ 今日任务部分失败，详见上方 💢 日志")
 		return nil
 	}
+	slog.Info("🚀 今日任务执行成功")
+	return nil
+}
 
-	executor := workflow.NewTaskExecutor(db, workflow.GetRegisteredTasks())
+// finishRun 在运行结束后（无论成败）写 metrics、推送 webhook。
+// 这两步失败只记日志，不覆盖运行本身的结果。
+func finishRun(ctx context.Context, db database.DataRepository, executor *workflow.TaskExecutor, plan *workflow.WorkPlan,
+	opts CronOptions, targets []notify.Target, runErr error, started time.Time) {
+	if opts.MetricsTextfile == "" && len(targets) == 0 {
+		return
+	}
 
-	args := &workflow.TaskArgs{
-		Min:       min,
-		TempDir:   TempDir,
-		VipdocDir: VipdocDir,
-		Today:     today,
-		Plan:      plan,
+	finished := time.Now()
+	reports := executor.Report()
+	dates, err := workflow.LatestDates(db)
+	if err != nil {
+		slog.Warn("⚠️ 查询各表最新日期失败", "error", err)
 	}
 
-	taskNames := workflow.GetUpdateTaskNames()
+	if opts.MetricsTextfile != "" {
+		reg := metrics.NewRegistry()
+		metrics.RecordRun(reg, reports, runErr, finished)
+		metrics.RecordFreshness(reg, dates)
+		metrics.RecordProcess(reg)
+		if err := reg.WriteTextfile(opts.MetricsTextfile); err != nil {
+			slog.Warn("⚠️ 写入 metrics 失败", "error", err)
+		}
+	}
+
+	if len(targets) > 0 {
+		summary := notify.BuildSummary(plan.Reason, reports, runErr, dates, started, finished)
+		// 运行被信号中断时 ctx 已取消，通知仍要发出去。
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if err := notify.Send(sendCtx, targets, summary); err != nil {
+			slog.Warn("⚠️ webhook 推送失败", "error", err)
+		}
+	}
+}
 
-	if err := executor.Run(ctx, taskNames, args); err != nil {
-		return fmt.Errorf("workflow execution failed: %w", err)
+// dryRun 输出是给人看的报告，直接写 stdout，不走 logger。
+func dryRun(ctx context.Context, db database.DataRepository, executor *workflow.TaskExecutor, taskNames []string, args *workflow.TaskArgs) error {
+	plan := args.Plan
+	fmt.Println("🔍 dry-run: 不写数据库、不下载文件")
+	if plan.Reason != "" {
+		fmt.Println(plan.Reason)
+	}
+
+	fmt.Println("\n📋 执行计划")
+	fmt.Printf("  today            %s\n", plan.Today.Format("2006-01-02"))
+	if !plan.LastTradingDay.IsZero() {
+		fmt.Printf("  last trading day %s\n", plan.LastTradingDay.Format("2006-01-02"))
+	}
+	fmt.Printf("  NeedDaily        %v\n", plan.NeedDaily)
+	fmt.Printf("  NeedGbbq         %v\n", plan.NeedGbbq)
+	fmt.Printf("  NeedBasic        %v\n", plan.NeedBasic)
+	fmt.Printf("  NeedFactor       %v\n", plan.NeedFactor)
+	fmt.Printf("  NeedValuation    %v\n", plan.NeedValuation)
+	fmt.Printf("  NeedBlockStats   %v\n", plan.NeedBlockStats)
+	fmt.Printf("  NeedHolidays     %v\n", plan.NeedHolidays)
+	if len(plan.PatchedDaily) > 0 {
+		fmt.Printf("  PatchedDaily     %d 天 (在线补齐，待官方数据覆盖)\n", len(plan.PatchedDaily))
 	}
 
-	fmt.Println("🚀 今日任务执行成功")
+	previews, err := executor.Preview(ctx, taskNames, args)
+	if err != nil {
+		return err
+	}
+	fmt.Println("\n🧭 任务")
+	for _, p := range previews {
+		mark := "▶️ "
+		if !p.WillRun {
+			mark = "⏭️ "
+		}
+		deps := ""
+		if len(p.DependsOn) > 0 {
+			deps = " (依赖 " + strings.Join(p.DependsOn, ", ") + ")"
+		}
+		fmt.Printf("  %s %-20s %s%s\n", mark, p.Name, p.Reason, deps)
+	}
+	if !plan.AnyNeeded() {
+		fmt.Println("  ⚠️ 计划无需执行，cron 实际运行时会直接退出")
+	}
+
+	probes, err := workflow.ProbeRemote(ctx, db, args)
+	if err != nil {
+		return err
+	}
+	fmt.Println("\n🛰️  远端发布情况")
+	if len(probes) == 0 {
+		fmt.Println("  无待下载日期")
+	}
+	for _, p := range probes {
+		fmt.Printf("  [%s] %s\n", p.Label, p.Note)
+	}
 	return nil
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"github.com/jing2uo/tdx2db/database"
@@ -38,8 +39,8 @@ func Init(ctx context.Context, dbURI, dayFileDir string) error {
 	}
 
 	if count > 0 {
-		fmt.Printf("🙈 数据库已包含 %d 条日线记录\n", count)
-		fmt.Printf("🎉 无需初始化\n")
+		slog.Info(fmt.Sprintf("🙈 数据库已包含 %d 条日线记录", count), "rows", count)
+		slog.Info("🎉 无需初始化")
 		return nil
 	}
 
@@ -50,6 +51,7 @@ func Init(ctx context.Context, dbURI, dayFileDir string) error {
 		TempDir:    TempDir,
 		VipdocDir:  VipdocDir,
 		Today:      GetToday(),
+		Logger:     slog.Default(),
 	}
 
 	taskNames := workflow.GetInitTaskNames()
@@ -58,6 +60,6 @@ func Init(ctx context.Context, dbURI, dayFileDir string) error {
 		return fmt.Errorf("workflow execution failed: %w", err)
 	}
 
-	fmt.Println("🚀 初始化完成")
+	slog.Info("🚀 初始化完成")
 	return nil
 }
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+// Quotes 抓取一次实时行情快照写入 raw_quote_snapshot。
+// symbols 为空时按 classes 从 raw_symbol_name 取代码，classes 也为空则用默认分类。
+func Quotes(ctx context.Context, dbURI string, symbols, classes []string) error {
+	db, err := database.NewDB(dbURI)
+	if err != nil {
+		return fmt.Errorf("failed to create database driver: %w", err)
+	}
+
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+	defer closeOnlineHooks()
+
+	if err := db.InitSchema(); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	if err := checkSchemaVersion(db); err != nil {
+		return err
+	}
+
+	for i, s := range symbols {
+		symbols[i] = strings.ToLower(strings.TrimSpace(s))
+	}
+	if len(symbols) == 0 && len(classes) > 0 {
+		if symbols, err = db.GetSymbolsByClass(classes...); err != nil {
+			return err
+		}
+		if len(symbols) == 0 {
+			return fmt.Errorf("no symbols of class %s in raw_symbol_name, run cron first", strings.Join(classes, ","))
+		}
+	}
+
+	_, err = workflow.SnapshotQuotes(ctx, db, TempDir, symbols)
+	return err
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/server"
+)
+
+// Serve 启动只读 HTTP API，ctx 取消时优雅关闭。
+// 只读服务不建表：库结构缺失或版本不符时直接报错。
+func Serve(ctx context.Context, dbURI, listen string) error {
+	db, err := database.NewDB(dbURI)
+	if err != nil {
+		return fmt.Errorf("failed to create database driver: %w", err)
+	}
+
+	if err := db.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := checkSchemaVersion(db); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              listen,
+		Handler:           server.New(db).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("🌐 API 服务已启动", "listen", listen)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		slog.Info("🌐 API 服务已停止")
+		return nil
+	}
+}
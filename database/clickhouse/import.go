@@ -152,3 +152,24 @@ func (d *ClickHouseDriver) RebuildSymbolClass() error {
 	}
 	return nil
 }
+
+// DeleteDates 删除 dateCol 落在 dates 中的行。轻量 DELETE 默认同步完成，
+// 之后立即导入同日期数据不会被删掉。
+func (d *ClickHouseDriver) DeleteDates(meta *model.TableMeta, dateCol string, dates []time.Time) error {
+	if len(dates) == 0 {
+		return nil
+	}
+	values := make([]string, len(dates))
+	for i, dt := range dates {
+		values[i] = "'" + dt.Format("2006-01-02") + "'"
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE toDate(%s) IN (%s)",
+		meta.TableName, dateCol, strings.Join(values, ", "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if _, err := d.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("clickhouse delete dates from %s failed: %w", meta.TableName, err)
+	}
+	return nil
+}
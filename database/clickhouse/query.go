@@ -146,3 +146,46 @@ func (d *ClickHouseDriver) GetHolidays() ([]time.Time, error) {
 	}
 	return dates, nil
 }
+
+func (d *ClickHouseDriver) QueryRows(q model.RowQuery) (*model.RowSet, error) {
+	query, args, err := q.SQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.Queryx(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", q.Table, err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	set := &model.RowSet{Columns: cols}
+	for rows.Next() {
+		row, err := rows.SliceScan()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", q.Table, err)
+		}
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		set.Rows = append(set.Rows, row)
+	}
+	return set, rows.Err()
+}
+
+func (d *ClickHouseDriver) QueryInto(q model.RowQuery, dest interface{}) error {
+	query, args, err := q.SQL()
+	if err != nil {
+		return err
+	}
+	if err := d.db.Select(dest, query, args...); err != nil {
+		return fmt.Errorf("failed to query %s: %w", q.Table, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package clickhouse
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jing2uo/tdx2db/model"
+)
+
+func filledViews(t *testing.T) (map[string]int, []model.ViewDef) {
+	t.Helper()
+	order := map[string]int{}
+	var filled []model.ViewDef
+	for i, v := range model.AllViews() {
+		order[v.Name] = i
+		if strings.Contains(v.Name, "_filled_") {
+			filled = append(filled, v)
+		}
+	}
+	if len(filled) == 0 {
+		t.Fatal("no filled views registered")
+	}
+	return order, filled
+}
+
+// ClickHouse 分支不能混入 DuckDB 方言，ASOF JOIN 的不等式条件必须放在等值条件之后。
+func TestFilledViewsClickHouseSQL(t *testing.T) {
+	asof := regexp.MustCompile(`ASOF LEFT JOIN (\w+) v ON g\.symbol = v\.symbol AND g\.date >= v\.date`)
+	for _, v := range func() []model.ViewDef { _, f := filledViews(t); return f }() {
+		sql := v.ClickHouse
+		for _, duckOnly := range []string{"unnest(", "generate_series(", "isodow(", "INTERVAL 1 DAY"} {
+			if strings.Contains(sql, duckOnly) {
+				t.Errorf("%s: ClickHouse SQL contains DuckDB-only %q", v.Name, duckOnly)
+			}
+		}
+		for _, want := range []string{"arrayJoin(", "toDayOfWeek(", model.TableSymbolLifecycle.TableName, model.TableHoliday.TableName} {
+			if !strings.Contains(sql, want) {
+				t.Errorf("%s: ClickHouse SQL missing %q", v.Name, want)
+			}
+		}
+		if !asof.MatchString(sql) {
+			t.Errorf("%s: unexpected ASOF LEFT JOIN clause", v.Name)
+		}
+		if strings.Count(sql, "(") != strings.Count(sql, ")") {
+			t.Errorf("%s: unbalanced parentheses", v.Name)
+		}
+	}
+}
+
+// ClickHouse 建视图时会解析引用的表，被 ASOF JOIN 的日线视图必须先于填充视图创建。
+func TestFilledViewsCreatedAfterBaseView(t *testing.T) {
+	asof := regexp.MustCompile(`ASOF LEFT JOIN (\w+) v`)
+	order, filled := filledViews(t)
+	for _, v := range filled {
+		m := asof.FindStringSubmatch(v.ClickHouse)
+		if m == nil {
+			t.Fatalf("%s: no ASOF LEFT JOIN", v.Name)
+		}
+		base, ok := order[m[1]]
+		if !ok {
+			t.Fatalf("%s: base view %s not registered", v.Name, m[1])
+		}
+		if base >= order[v.Name] {
+			t.Errorf("%s: base view %s is created after it", v.Name, m[1])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package duckdb
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jing2uo/tdx2db/model"
 )
@@ -114,3 +115,21 @@ func (d *DuckDBDriver) RebuildSymbolClass() error {
 
 	return tx.Commit()
 }
+
+// DeleteDates 删除 dateCol 落在 dates 中的行，用于在线补齐数据被官方数据覆盖前的清理。
+func (d *DuckDBDriver) DeleteDates(meta *model.TableMeta, dateCol string, dates []time.Time) error {
+	if len(dates) == 0 {
+		return nil
+	}
+	placeholders := strings.Repeat("?,", len(dates))
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)",
+		meta.TableName, dateCol, placeholders[:len(placeholders)-1])
+	args := make([]any, len(dates))
+	for i, d := range dates {
+		args[i] = d
+	}
+	if _, err := d.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("duckdb delete dates from %s failed: %w", meta.TableName, err)
+	}
+	return nil
+}
@@ -27,7 +27,10 @@ type DataRepository interface {
 	ImportSymbolNames(csvPath string) error
 
 	TruncateTable(meta *model.TableMeta) error
+	DeleteDates(meta *model.TableMeta, dateCol string, dates []time.Time) error
 	Query(table string, conditions map[string]interface{}, dest interface{}) error
+	QueryRows(q model.RowQuery) (*model.RowSet, error)
+	QueryInto(q model.RowQuery, dest interface{}) error
 	QueryKlineDaily(symbol string, startDate, endDate *time.Time) ([]model.KlineDay, error)
 	GetLatestDate(tableName string, dateCol string) (time.Time, error)
 	GetMinDate(tableName string, dateCol string) (time.Time, error)
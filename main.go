@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"runtime/debug"
@@ -55,6 +56,12 @@ Database URI:
 
 const dayFileInfo = "通达信日线文件目录"
 const minInfo = "导入 1 分钟分时数据（可选）"
+const metricsTextfileInfo = "运行结束后写入 Prometheus 指标文件 (node_exporter textfile collector)"
+const onlineFillInfo = "g4day 缺失或未发布时用通达信在线行情补齐日线, 官方数据发布后自动覆盖"
+const webhookInfo = "运行结束后推送摘要, 可重复: [json|wecom|dingtalk|feishu|telegram=]<url>"
+const quotesInfo = "运行末尾抓取一次实时行情快照写入 raw_quote_snapshot"
+const benchmarkInfo = "raw_returns_daily 超额收益的基准指数代码 (默认沪深 300), 留空不计算超额收益"
+const dryRunInfo = "只打印执行计划并探测远端数据发布情况，不写库"
 
 func main() {
 	// 创建可取消的 context
@@ -66,18 +73,34 @@ func main() {
 
 	go func() {
 		sig := <-sigChan
-		fmt.Printf("\n🚨 收到信号 %v，正在退出...\n", sig)
+		slog.Warn(fmt.Sprintf("🚨 收到信号 %v，正在退出...", sig), "signal", sig.String())
 		cancel()
 	}()
 
 	versionStr := buildVersionString()
-	var tempDirOverride string
+	var (
+		tempDirOverride string
+		logLevel        string
+		logFormat       string
+		tdxHosts        []string
+		tdxMacHosts     []string
+		classRules      string
+	)
 	var rootCmd = &cobra.Command{
 		Use:           "tdx2db",
 		Short:         "Load TDX Data to DuckDB",
 		SilenceErrors: true,
 		Version:       versionStr,
 		PersistentPreRunE: func(c *cobra.Command, args []string) error {
+			if err := cmd.SetupLogger(logLevel, logFormat); err != nil {
+				return err
+			}
+			if err := cmd.OverrideTDXHosts(tdxHosts, tdxMacHosts); err != nil {
+				return err
+			}
+			if err := cmd.OverrideClassRules(classRules); err != nil {
+				return err
+			}
 			return cmd.OverrideTempDir(tempDirOverride)
 		},
 	}
@@ -88,6 +111,16 @@ func main() {
 	// 适用 $TMPDIR (常见 /tmp tmpfs) 容量被占满时的兜底。
 	rootCmd.PersistentFlags().StringVar(&tempDirOverride, "temp", "",
 		"临时文件父目录, 留空走 $TMPDIR")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info",
+		"日志级别: debug|info|warn|error")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text",
+		"日志格式: text (人读) | json (结构化, 便于采集)")
+	rootCmd.PersistentFlags().StringSliceVar(&tdxHosts, "tdx-hosts", nil,
+		"覆盖代码 / 行情主站列表, 逗号分隔: [name=]ip[:port]")
+	rootCmd.PersistentFlags().StringSliceVar(&tdxMacHosts, "tdx-mac-hosts", nil,
+		"覆盖板块主站列表, 逗号分隔: [name=]ip[:port]")
+	rootCmd.PersistentFlags().StringVar(&classRules, "class-rules", "",
+		"外部分类规则文件 (每行 market,prefix,class[,scale])，合并到内置规则之上")
 
 	var versionCmd = &cobra.Command{
 		Use:   "version",
@@ -101,6 +134,26 @@ func main() {
 		dbURI      string
 		dayFileDir string
 		minEnable  bool
+		dryRun     bool
+		onlineFill bool
+		benchmark  string
+
+		metricsTextfile string
+		webhooks        []string
+
+		listen string
+
+		quotesEnable bool
+		quoteSymbols []string
+		quoteClasses []string
+
+		classifyRebuild bool
+
+		aliasFile string
+
+		calendarNext      int
+		calendarBetween   []string
+		calendarIsTrading string
 	)
 
 	var initCmd = &cobra.Command{
@@ -117,9 +170,86 @@ func main() {
 		Use:   "cron",
 		Short: "Cron for update data and calc factor",
 		Example: `  tdx2db cron --dburi 'clickhouse://localhost' --min
-  tdx2db cron --dburi 'duckdb://./tdx.db'` + dbURIHelp,
+  tdx2db cron --dburi 'duckdb://./tdx.db'
+  tdx2db cron --dburi 'duckdb://./tdx.db' --min --dry-run` + dbURIHelp,
+		RunE: func(c *cobra.Command, args []string) error {
+			return cmd.Cron(ctx, dbURI, cmd.CronOptions{
+				Min:             minEnable,
+				DryRun:          dryRun,
+				OnlineFill:      onlineFill,
+				Quotes:          quotesEnable,
+				Benchmark:       benchmark,
+				MetricsTextfile: metricsTextfile,
+				Webhooks:        webhooks,
+			})
+		},
+	}
+
+	var serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a read-only HTTP/JSON API over the database",
+		Example: `  tdx2db serve --dburi 'duckdb://./tdx.db?access_mode=read_only' --listen :8080
+  curl 'localhost:8080/api/v1/daily/sz000001?fq=qfq&start=2024-01-01'
+  curl 'localhost:8080/api/v1/symbols?class=etf&format=csv'` + dbURIHelp,
+		RunE: func(c *cobra.Command, args []string) error {
+			return cmd.Serve(ctx, dbURI, listen)
+		},
+	}
+
+	var quotesCmd = &cobra.Command{
+		Use:   "quotes",
+		Short: "Snapshot realtime quotes into raw_quote_snapshot",
+		Example: `  tdx2db quotes --dburi 'duckdb://./tdx.db'
+  tdx2db quotes --dburi 'duckdb://./tdx.db' --symbols sh600000,sz000001
+  tdx2db quotes --dburi 'clickhouse://localhost' --class etf` + dbURIHelp,
 		RunE: func(c *cobra.Command, args []string) error {
-			return cmd.Cron(ctx, dbURI, minEnable)
+			return cmd.Quotes(ctx, dbURI, quoteSymbols, quoteClasses)
+		},
+	}
+
+	var classifyCmd = &cobra.Command{
+		Use:   "classify",
+		Short: "Show symbol class rules or rebuild raw_symbol_class",
+		Example: `  tdx2db classify --class-rules ./rules.csv
+  tdx2db classify --rebuild --dburi 'duckdb://./tdx.db' --class-rules ./rules.csv` + dbURIHelp,
+		RunE: func(c *cobra.Command, args []string) error {
+			if classifyRebuild && dbURI == "" {
+				return fmt.Errorf("--rebuild requires --dburi")
+			}
+			return cmd.Classify(ctx, dbURI, classifyRebuild)
+		},
+	}
+
+	var aliasCmd = &cobra.Command{
+		Use:   "alias",
+		Short: "Show or import symbol code changes used by v_stock_stitched_*",
+		Example: `  tdx2db alias --dburi 'duckdb://./tdx.db'
+  tdx2db alias --dburi 'duckdb://./tdx.db' --file ./aliases.csv` + dbURIHelp,
+		RunE: func(c *cobra.Command, args []string) error {
+			return cmd.Alias(ctx, dbURI, aliasFile)
+		},
+	}
+
+	var calendarCmd = &cobra.Command{
+		Use:   "calendar",
+		Short: "Query trading days from raw_holidays",
+		Example: `  tdx2db calendar --dburi 'duckdb://./tdx.db' --next 5
+  tdx2db calendar --dburi 'duckdb://./tdx.db' --between 2024-01-01 2024-01-31
+  tdx2db calendar --dburi 'duckdb://./tdx.db' --is-trading 2024-02-09` + dbURIHelp,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			// 允许 --between a b：第二个日期落在位置参数里。
+			between := calendarBetween
+			if len(between) == 1 && len(args) == 1 {
+				between = append(between, args[0])
+			} else if len(args) > 0 {
+				return fmt.Errorf("unexpected argument %q", args[0])
+			}
+			return cmd.Calendar(ctx, dbURI, cmd.CalendarOptions{
+				Next:      calendarNext,
+				Between:   between,
+				IsTrading: calendarIsTrading,
+			})
 		},
 	}
 
@@ -133,9 +263,47 @@ func main() {
 	cronCmd.Flags().StringVar(&dbURI, "dburi", "", dbURIInfo)
 	cronCmd.MarkFlagRequired("dburi")
 	cronCmd.Flags().BoolVar(&minEnable, "min", false, minInfo)
+	cronCmd.Flags().BoolVar(&dryRun, "dry-run", false, dryRunInfo)
+	cronCmd.Flags().BoolVar(&onlineFill, "online-fill", false, onlineFillInfo)
+	cronCmd.Flags().BoolVar(&quotesEnable, "quotes", false, quotesInfo)
+	cronCmd.Flags().StringVar(&benchmark, "benchmark", "sh000300", benchmarkInfo)
+	cronCmd.Flags().StringVar(&metricsTextfile, "metrics-textfile", "", metricsTextfileInfo)
+	cronCmd.Flags().StringArrayVar(&webhooks, "webhook", nil, webhookInfo)
+
+	// Serve Flags
+	serveCmd.Flags().StringVar(&dbURI, "dburi", "", dbURIInfo)
+	serveCmd.MarkFlagRequired("dburi")
+	serveCmd.Flags().StringVar(&listen, "listen", ":8080", "HTTP 监听地址")
+
+	// Quotes Flags
+	quotesCmd.Flags().StringVar(&dbURI, "dburi", "", dbURIInfo)
+	quotesCmd.MarkFlagRequired("dburi")
+	quotesCmd.Flags().StringSliceVar(&quoteSymbols, "symbols", nil, "代码列表, 逗号分隔; 留空取 raw_symbol_name 全部代码")
+	quotesCmd.Flags().StringSliceVar(&quoteClasses, "class", nil, "按分类取代码: stock|bstock|etf|index, 可多选")
+
+	// Classify Flags
+	classifyCmd.Flags().StringVar(&dbURI, "dburi", "", dbURIInfo)
+	classifyCmd.Flags().BoolVar(&classifyRebuild, "rebuild", false, "按当前规则重建 raw_symbol_class 并列出分类变化的代码")
+
+	// Alias Flags
+	aliasCmd.Flags().StringVar(&dbURI, "dburi", "", dbURIInfo)
+	aliasCmd.MarkFlagRequired("dburi")
+	aliasCmd.Flags().StringVar(&aliasFile, "file", "", "代码变更文件 (每行 old_symbol,new_symbol,effective_date[,ratio])，合并到 raw_symbol_alias")
+
+	// Calendar Flags
+	calendarCmd.Flags().StringVar(&dbURI, "dburi", "", dbURIInfo)
+	calendarCmd.MarkFlagRequired("dburi")
+	calendarCmd.Flags().IntVar(&calendarNext, "next", 0, "列出今天之后的 N 个交易日")
+	calendarCmd.Flags().StringSliceVar(&calendarBetween, "between", nil, "列出两个日期之间 (含) 的交易日: --between 2024-01-01 2024-01-31")
+	calendarCmd.Flags().StringVar(&calendarIsTrading, "is-trading", "", "判断某天是否为交易日 (YYYY-MM-DD)")
 
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(cronCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(quotesCmd)
+	rootCmd.AddCommand(classifyCmd)
+	rootCmd.AddCommand(aliasCmd)
+	rootCmd.AddCommand(calendarCmd)
 	rootCmd.AddCommand(versionCmd)
 
 	cobra.OnFinalize(func() {
@@ -144,10 +312,10 @@ func main() {
 
 	if err := rootCmd.Execute(); err != nil {
 		if err == context.Canceled {
-			fmt.Fprintln(os.Stderr, "✅ 任务安全中断")
+			slog.Warn("✅ 任务安全中断")
 			os.Exit(0)
 		}
-		fmt.Fprintf(os.Stderr, "🛑 错误: %v\n", err)
+		slog.Error("🛑 错误", "error", err)
 		os.Exit(1)
 	}
 }
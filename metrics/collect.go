@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/jing2uo/tdx2db/tdx"
+	"github.com/jing2uo/tdx2db/utils"
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+// RecordRun 记录一次 cron 的整体结果与各任务的耗时 / 行数 / 状态。
+func RecordRun(reg *Registry, reports []workflow.TaskReport, runErr error, finished time.Time) {
+	success := 1.0
+	if runErr != nil {
+		success = 0
+	}
+	reg.Set("tdx2db_run_success", "Whether the last run finished without a stopping error.", success)
+	reg.Set("tdx2db_run_timestamp_seconds", "Unix time the last run finished.", float64(finished.Unix()))
+
+	for _, r := range reports {
+		task := Label{"task", r.Name}
+		reg.Set("tdx2db_task_duration_seconds", "Task execution time in seconds.", r.Duration.Seconds(), task)
+		reg.Set("tdx2db_task_rows", "Rows reported by the task.", float64(r.Rows), task)
+
+		failed := 0.0
+		if workflow.HasFailures([]workflow.TaskReport{r}) {
+			failed = 1
+		}
+		reg.Set("tdx2db_task_failed", "1 if the task failed in the last run.", failed, task)
+		for _, st := range []workflow.TaskState{
+			workflow.StatePending, workflow.StateCompleted, workflow.StateSkipped, workflow.StateFailed,
+		} {
+			v := 0.0
+			if r.State == st {
+				v = 1
+			}
+			reg.Set("tdx2db_task_state", "Task state in the last run (one-hot).", v, task, Label{"state", string(st)})
+		}
+	}
+}
+
+// RecordFreshness 记录各 raw 表的最新日期，空表记 0。
+func RecordFreshness(reg *Registry, dates []workflow.TableDate) {
+	for _, d := range dates {
+		v := 0.0
+		if !d.Latest.IsZero() {
+			v = float64(d.Latest.Unix())
+		}
+		reg.Set("tdx2db_table_latest_date_timestamp_seconds",
+			"Unix time of the latest date in the table (0 if empty).", v, Label{"table", d.Table})
+	}
+}
+
+// RecordProcess 记录进程内累计的下载字节数与 TDX 在线主站测速结果。
+func RecordProcess(reg *Registry) {
+	reg.Set("tdx2db_download_bytes", "Bytes downloaded by this process.", float64(utils.DownloadedBytes()))
+
+	for _, h := range tdx.HostProbes() {
+		host := []Label{{"host", h.Addr}, {"name", h.Name}}
+		up := 0.0
+		if h.Up {
+			up = 1
+			reg.Set("tdx2db_tdx_host_latency_seconds", "TCP connect latency to the TDX online host.",
+				h.Latency.Seconds(), host...)
+		}
+		reg.Set("tdx2db_tdx_host_up", "Whether the TDX online host accepted a TCP connection.", up, host...)
+	}
+}
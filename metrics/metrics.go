@@ -0,0 +1,156 @@
+// Package metrics 以 Prometheus 文本格式导出运行指标。
+// 不依赖 client_golang：指标只有几十条 gauge，手写 exposition 足够，
+// 同一份输出既可写 node_exporter textfile，也可挂到 HTTP /metrics。
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Label 是一个 Prometheus 标签。
+type Label struct {
+	Name  string
+	Value string
+}
+
+type sample struct {
+	labels []Label
+	value  float64
+}
+
+type family struct {
+	name    string
+	help    string
+	samples map[string]sample
+}
+
+// Registry 保存一组 gauge，后写覆盖同名同标签的旧值。并发安全。
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*family{}}
+}
+
+// Set 设置 gauge 值；help 只在首次出现时生效。
+func (r *Registry) Set(name, help string, value float64, labels ...Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, samples: map[string]sample{}}
+		r.families[name] = f
+	}
+	f.samples[labelKey(labels)] = sample{labels: labels, value: value}
+}
+
+// WriteText 按 Prometheus text exposition format 输出，family 与样本均排序，输出稳定。
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for n := range r.families {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, n := range names {
+		f := r.families[n]
+		fmt.Fprintf(&sb, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&sb, "# TYPE %s gauge\n", f.name)
+
+		keys := make([]string, 0, len(f.samples))
+		for k := range f.samples {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s := f.samples[k]
+			sb.WriteString(f.name)
+			if len(s.labels) > 0 {
+				sb.WriteByte('{')
+				for i, l := range s.labels {
+					if i > 0 {
+						sb.WriteByte(',')
+					}
+					fmt.Fprintf(&sb, "%s=\"%s\"", l.Name, escapeLabel(l.Value))
+				}
+				sb.WriteByte('}')
+			}
+			sb.WriteByte(' ')
+			sb.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+			sb.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteTextfile 原子写入 node_exporter textfile：先写同目录临时文件再 rename，
+// 避免 collector 读到半截文件。
+func (r *Registry) WriteTextfile(path string) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".tdx2db-metrics-")
+	if err != nil {
+		return fmt.Errorf("create metrics temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := r.WriteText(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close metrics temp file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("chmod metrics file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename metrics file: %w", err)
+	}
+	return nil
+}
+
+// Handler 返回输出当前指标的 http.Handler，用于常驻进程的 /metrics。
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(w)
+	})
+}
+
+func labelKey(labels []Label) string {
+	var sb strings.Builder
+	for _, l := range labels {
+		sb.WriteString(l.Name)
+		sb.WriteByte('=')
+		sb.WriteString(l.Value)
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+func escapeHelp(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteTextSortedAndEscaped(t *testing.T) {
+	reg := NewRegistry()
+	reg.Set("tdx2db_task_rows", "Rows reported by the task.", 12, Label{"task", "update_gbbq"})
+	reg.Set("tdx2db_task_rows", "ignored", 3, Label{"task", "calc_basic"})
+	reg.Set("tdx2db_run_success", "Whether the last run succeeded.", 1)
+	reg.Set("tdx2db_task_rows", "", 5, Label{"task", "calc_basic"}) // 覆盖旧值
+	reg.Set("tdx2db_tdx_host_up", "Host up.", 1, Label{"name", `主站"1"`})
+
+	var buf bytes.Buffer
+	if err := reg.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP tdx2db_run_success Whether the last run succeeded.
+# TYPE tdx2db_run_success gauge
+tdx2db_run_success 1
+# HELP tdx2db_task_rows Rows reported by the task.
+# TYPE tdx2db_task_rows gauge
+tdx2db_task_rows{task="calc_basic"} 5
+tdx2db_task_rows{task="update_gbbq"} 12
+# HELP tdx2db_tdx_host_up Host up.
+# TYPE tdx2db_tdx_host_up gauge
+tdx2db_tdx_host_up{name="主站\"1\""} 1
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTextfileReplacesAtomically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tdx2db.prom")
+	reg := NewRegistry()
+	reg.Set("tdx2db_run_success", "ok", 0)
+	if err := reg.WriteTextfile(path); err != nil {
+		t.Fatal(err)
+	}
+	reg.Set("tdx2db_run_success", "ok", 1)
+	if err := reg.WriteTextfile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("tdx2db_run_success 1\n")) {
+		t.Fatalf("textfile not updated: %s", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("temp files left behind: %d entries", len(entries))
+	}
+}
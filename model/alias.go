@@ -0,0 +1,161 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// raw_symbol_alias 的来源。
+const (
+	AliasSourceUser = "user"
+	AliasSourceTDX  = "tdx"
+)
+
+// bjMigrationWindow 是北交所旧代码停牌到 920 新代码首个交易日之间允许的最大间隔。
+const bjMigrationWindow = 30 * 24 * time.Hour
+
+// LoadSymbolAliases 读取用户维护的代码变更文件。
+func LoadSymbolAliases(path string) ([]SymbolAlias, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open symbol aliases: %w", err)
+	}
+	defer f.Close()
+	return ParseSymbolAliases(f)
+}
+
+// ParseSymbolAliases 解析代码变更文件，每行 "old_symbol,new_symbol,effective_date[,ratio]"，
+// 空行与 # 开头的行忽略，ratio 缺省为 1，如:
+//
+//	# 北交所代码切换
+//	bj830799,bj920799,2025-10-09
+//	sh600001,sh600002,2024-05-06,0.8
+func ParseSymbolAliases(r io.Reader) ([]SymbolAlias, error) {
+	var aliases []SymbolAlias
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.Split(text, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if len(parts) < 3 || len(parts) > 4 {
+			return nil, fmt.Errorf("symbol aliases line %d: want old_symbol,new_symbol,effective_date[,ratio], got %q", line, text)
+		}
+		a := SymbolAlias{
+			OldSymbol: strings.ToLower(parts[0]),
+			NewSymbol: strings.ToLower(parts[1]),
+			Ratio:     1,
+			Source:    AliasSourceUser,
+		}
+		for _, s := range []string{a.OldSymbol, a.NewSymbol} {
+			if !isMarketSymbol(s) {
+				return nil, fmt.Errorf("symbol aliases line %d: invalid symbol %q", line, s)
+			}
+		}
+		if a.OldSymbol == a.NewSymbol {
+			return nil, fmt.Errorf("symbol aliases line %d: old and new symbol are the same", line)
+		}
+		d, err := time.Parse("2006-01-02", parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("symbol aliases line %d: invalid effective date %q", line, parts[2])
+		}
+		a.EffectiveDate = d
+		if len(parts) == 4 && parts[3] != "" {
+			ratio, err := strconv.ParseFloat(parts[3], 64)
+			if err != nil || ratio <= 0 {
+				return nil, fmt.Errorf("symbol aliases line %d: invalid ratio %q", line, parts[3])
+			}
+			a.Ratio = ratio
+		}
+		aliases = append(aliases, a)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read symbol aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// isMarketSymbol 判断是否为 "sh/sz/bj + 6 位数字" 形式的代码。
+func isMarketSymbol(s string) bool {
+	if len(s) != 8 {
+		return false
+	}
+	switch s[:2] {
+	case "sh", "sz", "bj":
+	default:
+		return false
+	}
+	return strings.Trim(s[2:], "0123456789") == ""
+}
+
+// MergeSymbolAliases 按 old_symbol 合并两组映射，按 old_symbol 排序返回。
+// override 为 true 时 extra 覆盖已有映射 (用户导入)，否则只补充缺失的 (推断结果不覆盖用户数据)。
+func MergeSymbolAliases(existing, extra []SymbolAlias, override bool) []SymbolAlias {
+	byOld := make(map[string]SymbolAlias, len(existing)+len(extra))
+	for _, a := range existing {
+		byOld[a.OldSymbol] = a
+	}
+	for _, a := range extra {
+		if _, ok := byOld[a.OldSymbol]; ok && !override {
+			continue
+		}
+		byOld[a.OldSymbol] = a
+	}
+	out := make([]SymbolAlias, 0, len(byOld))
+	for _, a := range byOld {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OldSymbol < out[j].OldSymbol })
+	return out
+}
+
+// SeedSymbolAliases 从生命周期推断北交所代码切换：920 号段新代码取旧代码 (43/83/87/88 号段)
+// 后三位，旧代码最后交易日之后 bjMigrationWindow 内新代码开始交易，且候选唯一时才关联。
+func SeedSymbolAliases(lifecycle []SymbolLifecycle) []SymbolAlias {
+	oldBySuffix := make(map[string][]SymbolLifecycle)
+	for _, l := range lifecycle {
+		if l.LastDate == nil || !strings.HasPrefix(l.Symbol, "bj") {
+			continue
+		}
+		switch l.Symbol[2:4] {
+		case "43", "83", "87", "88":
+			oldBySuffix[l.Symbol[5:]] = append(oldBySuffix[l.Symbol[5:]], l)
+		}
+	}
+
+	var out []SymbolAlias
+	for _, l := range lifecycle {
+		if l.FirstDate == nil || !strings.HasPrefix(l.Symbol, "bj920") {
+			continue
+		}
+		first := lifecycleDate(*l.FirstDate)
+		var matched []string
+		for _, old := range oldBySuffix[l.Symbol[5:]] {
+			last := lifecycleDate(*old.LastDate)
+			if last.Before(first) && first.Sub(last) <= bjMigrationWindow {
+				matched = append(matched, old.Symbol)
+			}
+		}
+		if len(matched) == 1 {
+			out = append(out, SymbolAlias{
+				OldSymbol:     matched[0],
+				NewSymbol:     l.Symbol,
+				EffectiveDate: first,
+				Ratio:         1,
+				Source:        AliasSourceTDX,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OldSymbol < out[j].OldSymbol })
+	return out
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSymbolAliases(t *testing.T) {
+	aliases, err := ParseSymbolAliases(strings.NewReader(`
+# 北交所代码切换
+bj830799, bj920799, 2025-10-09
+SH600001,sh600002,2024-05-06,0.8
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 2 {
+		t.Fatalf("expected 2 aliases, got %+v", aliases)
+	}
+	if a := aliases[0]; a.OldSymbol != "bj830799" || a.NewSymbol != "bj920799" || a.Ratio != 1 || a.Source != AliasSourceUser ||
+		!a.EffectiveDate.Equal(time.Date(2025, 10, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected alias: %+v", a)
+	}
+	if a := aliases[1]; a.OldSymbol != "sh600001" || a.Ratio != 0.8 {
+		t.Fatalf("unexpected alias: %+v", a)
+	}
+
+	for _, bad := range []string{
+		"sh600001,sh600002",
+		"600001,sh600002,2024-05-06",
+		"sh600001,sh600001,2024-05-06",
+		"sh600001,sh600002,20240506",
+		"sh600001,sh600002,2024-05-06,0",
+	} {
+		if _, err := ParseSymbolAliases(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestMergeSymbolAliases(t *testing.T) {
+	user := []SymbolAlias{{OldSymbol: "bj830799", NewSymbol: "bj920799", Ratio: 1, Source: AliasSourceUser}}
+	seed := []SymbolAlias{
+		{OldSymbol: "bj830799", NewSymbol: "bj920000", Ratio: 1, Source: AliasSourceTDX},
+		{OldSymbol: "bj430047", NewSymbol: "bj920047", Ratio: 1, Source: AliasSourceTDX},
+	}
+
+	got := MergeSymbolAliases(user, seed, false)
+	if len(got) != 2 || got[0].OldSymbol != "bj430047" || got[1].NewSymbol != "bj920799" {
+		t.Fatalf("seed must not override user alias: %+v", got)
+	}
+	got = MergeSymbolAliases(user, seed, true)
+	if got[1].NewSymbol != "bj920000" {
+		t.Fatalf("override should replace alias: %+v", got)
+	}
+}
+
+func TestSeedSymbolAliases(t *testing.T) {
+	d := func(month, day int) *time.Time {
+		v := time.Date(2025, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		return &v
+	}
+	lifecycle := []SymbolLifecycle{
+		{Symbol: "bj830799", FirstDate: d(1, 2), LastDate: d(10, 8)},
+		{Symbol: "bj920799", FirstDate: d(10, 9), LastDate: d(10, 20)},
+		// 两个旧代码后三位相同，不关联。
+		{Symbol: "bj430047", FirstDate: d(1, 2), LastDate: d(10, 8)},
+		{Symbol: "bj870047", FirstDate: d(1, 2), LastDate: d(10, 8)},
+		{Symbol: "bj920047", FirstDate: d(10, 9), LastDate: d(10, 20)},
+		// 旧代码早已停止交易，超出切换窗口。
+		{Symbol: "bj831001", FirstDate: d(1, 2), LastDate: d(3, 1)},
+		{Symbol: "bj920001", FirstDate: d(10, 9), LastDate: d(10, 20)},
+	}
+
+	got := SeedSymbolAliases(lifecycle)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 seeded alias, got %+v", got)
+	}
+	if a := got[0]; a.OldSymbol != "bj830799" || a.NewSymbol != "bj920799" || a.Source != AliasSourceTDX ||
+		!a.EffectiveDate.Equal(*d(10, 9)) {
+		t.Fatalf("unexpected seeded alias: %+v", a)
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import "strings"
+
+// MatchCBondUnderlying 按名称推断可转债的正股。通达信代码表不含正股字段，
+// 转债简称通常取自正股简称 (平安银行 → 平银转债、蓝帆医疗 → 蓝帆转2)，
+// 因此取转债名中 "转" / "定" 之前的部分，在股票名称中按首字相同的子序列匹配；
+// 候选不唯一时优先取以该前缀开头的股票，仍不唯一则不关联。
+func MatchCBondUnderlying(names []SymbolName) []CBondUnderlying {
+	var stocks []SymbolName
+	for _, n := range names {
+		if n.Class == ClassStock {
+			stocks = append(stocks, SymbolName{Symbol: n.Symbol, Name: normalizeStockName(n.Name), Class: n.Class})
+		}
+	}
+
+	var out []CBondUnderlying
+	for _, n := range names {
+		if n.Class != ClassCBond {
+			continue
+		}
+		key := cbondNameKey(n.Name)
+		if len([]rune(key)) < 2 {
+			continue
+		}
+		var matched, prefixed []string
+		for _, s := range stocks {
+			if !runeSubsequence(key, s.Name) {
+				continue
+			}
+			matched = append(matched, s.Symbol)
+			if strings.HasPrefix(s.Name, key) {
+				prefixed = append(prefixed, s.Symbol)
+			}
+		}
+		switch {
+		case len(matched) == 1:
+			out = append(out, CBondUnderlying{Symbol: n.Symbol, StockSymbol: matched[0]})
+		case len(prefixed) == 1:
+			out = append(out, CBondUnderlying{Symbol: n.Symbol, StockSymbol: prefixed[0]})
+		}
+	}
+	return out
+}
+
+// cbondNameKey 取转债简称中 "转" / "定" 之前的部分，如 "平银转债" → "平银"。
+func cbondNameKey(name string) string {
+	name = strings.ReplaceAll(name, " ", "")
+	if i := strings.IndexAny(name, "转定"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// normalizeStockName 去掉空格与 ST / *ST 标记。
+func normalizeStockName(name string) string {
+	name = strings.ReplaceAll(name, " ", "")
+	name = strings.TrimPrefix(name, "*")
+	return strings.TrimPrefix(name, "ST")
+}
+
+// runeSubsequence 判断 key 是否为 name 的子序列，且两者首字相同。
+func runeSubsequence(key, name string) bool {
+	k, n := []rune(key), []rune(name)
+	if len(k) == 0 || len(n) == 0 || k[0] != n[0] {
+		return false
+	}
+	i := 0
+	for _, r := range n {
+		if i < len(k) && r == k[i] {
+			i++
+		}
+	}
+	return i == len(k)
+}
@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+func TestMatchCBondUnderlying(t *testing.T) {
+	names := []SymbolName{
+		{Symbol: "sz000001", Name: "平安银行", Class: ClassStock},
+		{Symbol: "sz002382", Name: "蓝帆医疗", Class: ClassStock},
+		{Symbol: "sh600030", Name: "中信证券", Class: ClassStock},
+		{Symbol: "sh601998", Name: "中信银行", Class: ClassStock},
+		{Symbol: "sz002497", Name: "*ST雅化", Class: ClassStock},
+		{Symbol: "sh000001", Name: "上证指数", Class: ClassIndex},
+		{Symbol: "sz127000", Name: "平银转债", Class: ClassCBond},
+		{Symbol: "sz128108", Name: "蓝帆转2", Class: ClassCBond},
+		{Symbol: "sh113021", Name: "中信转债", Class: ClassCBond},
+		{Symbol: "sz127099", Name: "雅化转债", Class: ClassCBond},
+	}
+
+	got := MatchCBondUnderlying(names)
+	want := map[string]string{
+		"sz127000": "sz000001",
+		"sz128108": "sz002382",
+		"sz127099": "sz002497",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d links, got %+v", len(want), got)
+	}
+	for _, l := range got {
+		if want[l.Symbol] != l.StockSymbol {
+			t.Fatalf("unexpected link %+v", l)
+		}
+	}
+}
+
+func TestClassifyCBond(t *testing.T) {
+	for _, s := range []string{"sh110059", "sh113021", "sh118000", "sz123001", "sz127000", "sz128108"} {
+		if got := ClassifyCode(s); got != ClassCBond {
+			t.Fatalf("ClassifyCode(%q) = %q, want cbond", s, got)
+		}
+		if PriceScale(s) != 1000 {
+			t.Fatalf("PriceScale(%q) = %v, want 1000", s, PriceScale(s))
+		}
+	}
+}
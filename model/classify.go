@@ -1,6 +1,13 @@
 package model
 
-import "strings"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
 
 const (
 	ClassStock   = "stock"
@@ -8,12 +15,14 @@ const (
 	ClassIndex   = "index"
 	ClassETF     = "etf"
 	ClassBlock   = "block"
+	ClassCBond   = "cbond"
 	ClassUnknown = "unknown"
 )
 
 // 规则按 (市场, 数字前缀) 匹配,前缀最长优先。
 // 不在规则里的代码归为 unknown。
-var classRules = []struct {
+// 内置规则之外可以用 SetClassRules 合并外部规则文件 (见 LoadClassRules)。
+var builtinClassRules = []struct {
 	Market string
 	Prefix string
 	Class  string
@@ -32,6 +41,9 @@ var classRules = []struct {
 	{"sh", "50", ClassETF}, {"sh", "51", ClassETF},
 	{"sh", "52", ClassETF}, {"sh", "53", ClassETF},
 	{"sh", "56", ClassETF}, {"sh", "58", ClassETF},
+	// 沪市可转债 (110/111 主板, 113 原中小盘, 118 科创板)
+	{"sh", "110", ClassCBond}, {"sh", "111", ClassCBond},
+	{"sh", "113", ClassCBond}, {"sh", "118", ClassCBond},
 
 	// 深市股票
 	{"sz", "000", ClassStock}, {"sz", "001", ClassStock},
@@ -45,6 +57,9 @@ var classRules = []struct {
 	// 深市 ETF (15) / LOF (16/18)
 	{"sz", "15", ClassETF}, {"sz", "16", ClassETF},
 	{"sz", "18", ClassETF},
+	// 深市可转债 (123 创业板, 127 主板, 128 原中小板)
+	{"sz", "123", ClassCBond}, {"sz", "127", ClassCBond},
+	{"sz", "128", ClassCBond},
 
 	// 北交所股票
 	{"bj", "43", ClassStock}, {"bj", "83", ClassStock},
@@ -54,17 +69,123 @@ var classRules = []struct {
 	{"bj", "899", ClassIndex},
 }
 
-// ClassifyCode 根据 symbol (如 "sh600000") 返回所属分类。
-// 未匹配到任何规则返回 ClassUnknown。
-func ClassifyCode(symbol string) string {
+// ClassRule 是一条分类规则。Scale 为 0 时按分类取默认价格系数 (见 PriceScale)。
+type ClassRule struct {
+	Market string
+	Prefix string
+	Class  string
+	Scale  float64
+}
+
+// classRules 是生效中的规则：内置规则，再按 (market, prefix) 合并外部规则。
+var classRules = defaultClassRules()
+
+func defaultClassRules() []ClassRule {
+	rules := make([]ClassRule, len(builtinClassRules))
+	for i, r := range builtinClassRules {
+		rules[i] = ClassRule{Market: r.Market, Prefix: r.Prefix, Class: r.Class}
+	}
+	return rules
+}
+
+// knownClasses 是规则文件允许使用的分类。
+var knownClasses = map[string]bool{
+	ClassStock: true, ClassBStock: true, ClassIndex: true, ClassETF: true,
+	ClassBlock: true, ClassCBond: true, ClassUnknown: true,
+}
+
+// SetClassRules 把 extra 合并到内置规则上：(market, prefix) 相同的覆盖内置规则，
+// 其余追加。传 nil 恢复内置规则。应在启动时、任何分类调用之前执行。
+func SetClassRules(extra []ClassRule) {
+	rules := defaultClassRules()
+	index := make(map[string]int, len(rules))
+	for i, r := range rules {
+		index[r.Market+r.Prefix] = i
+	}
+	for _, r := range extra {
+		if i, ok := index[r.Market+r.Prefix]; ok {
+			rules[i] = r
+			continue
+		}
+		index[r.Market+r.Prefix] = len(rules)
+		rules = append(rules, r)
+	}
+	classRules = rules
+}
+
+// ClassRules 返回当前生效的规则副本。
+func ClassRules() []ClassRule {
+	out := make([]ClassRule, len(classRules))
+	copy(out, classRules)
+	return out
+}
+
+// LoadClassRules 读取外部规则文件。
+func LoadClassRules(path string) ([]ClassRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open class rules: %w", err)
+	}
+	defer f.Close()
+	return ParseClassRules(f)
+}
+
+// ParseClassRules 解析规则文件，每行 "market,prefix,class[,scale]"，
+// 空行与 # 开头的行忽略，如:
+//
+//	# 北交所 920 号段
+//	bj,920,stock
+//	sh,59,etf,1000
+func ParseClassRules(r io.Reader) ([]ClassRule, error) {
+	var rules []ClassRule
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.Split(text, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if len(parts) < 3 || len(parts) > 4 {
+			return nil, fmt.Errorf("class rules line %d: want market,prefix,class[,scale], got %q", line, text)
+		}
+		rule := ClassRule{Market: strings.ToLower(parts[0]), Prefix: parts[1], Class: strings.ToLower(parts[2])}
+		if rule.Market != "sh" && rule.Market != "sz" && rule.Market != "bj" {
+			return nil, fmt.Errorf("class rules line %d: unknown market %q", line, parts[0])
+		}
+		if rule.Prefix == "" || strings.Trim(rule.Prefix, "0123456789") != "" {
+			return nil, fmt.Errorf("class rules line %d: prefix must be digits, got %q", line, parts[1])
+		}
+		if !knownClasses[rule.Class] {
+			return nil, fmt.Errorf("class rules line %d: unknown class %q", line, parts[2])
+		}
+		if len(parts) == 4 && parts[3] != "" {
+			scale, err := strconv.ParseFloat(parts[3], 64)
+			if err != nil || scale <= 0 {
+				return nil, fmt.Errorf("class rules line %d: invalid scale %q", line, parts[3])
+			}
+			rule.Scale = scale
+		}
+		rules = append(rules, rule)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read class rules: %w", err)
+	}
+	return rules, nil
+}
+
+// matchRule 返回 symbol 命中的最长前缀规则。
+func matchRule(symbol string) (ClassRule, bool) {
 	if len(symbol) < 3 {
-		return ClassUnknown
+		return ClassRule{}, false
 	}
 	market := symbol[:2]
 	num := symbol[2:]
 
-	best := ""
-	bestClass := ClassUnknown
+	var best ClassRule
+	found := false
 	for _, r := range classRules {
 		if r.Market != market {
 			continue
@@ -72,26 +193,41 @@ func ClassifyCode(symbol string) string {
 		if !strings.HasPrefix(num, r.Prefix) {
 			continue
 		}
-		if len(r.Prefix) > len(best) {
-			best = r.Prefix
-			bestClass = r.Class
+		if len(r.Prefix) > len(best.Prefix) {
+			best = r
+			found = true
 		}
 	}
-	return bestClass
+	return best, found
+}
+
+// ClassifyCode 根据 symbol (如 "sh600000") 返回所属分类。
+// 未匹配到任何规则返回 ClassUnknown。
+func ClassifyCode(symbol string) string {
+	if r, ok := matchRule(symbol); ok {
+		return r.Class
+	}
+	return ClassUnknown
 }
 
 // PriceScale 返回 TDX 日线/分钟线原始整数价格的换算系数。
-// TDX 把不同品种用不同分辨率存储:
-//   - 股票/指数/板块/北交所/可转债: 单位 0.01 元 (分),  返回 100
+// 命中的规则带 Scale 时直接使用，否则按分类:
+//   - 股票/指数/板块/北交所: 单位 0.01 元 (分),  返回 100
 //   - ETF/LOF/老封基 (class=etf): 单位 0.001 元,       返回 1000
+//   - 可转债 (class=cbond): 单位 0.001 元,            返回 1000
 //   - 沪市 B 股 (sh900) / 深市 B 股 (sz20): 单位 0.001 元 (USD/HKD), 返回 1000
 //
 // 未识别的 symbol 默认按股票 (100) 处理。
 func PriceScale(symbol string) float64 {
-	if ClassifyCode(symbol) == ClassETF {
-		return 1000.0
+	r, ok := matchRule(symbol)
+	if !ok {
+		return 100.0
+	}
+	if r.Scale > 0 {
+		return r.Scale
 	}
-	if strings.HasPrefix(symbol, "sh900") || strings.HasPrefix(symbol, "sz20") {
+	switch r.Class {
+	case ClassETF, ClassCBond, ClassBStock:
 		return 1000.0
 	}
 	return 100.0
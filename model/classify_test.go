@@ -1,6 +1,9 @@
 package model
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestClassifyCodeSeparatesBShare(t *testing.T) {
 	tests := []struct {
@@ -66,3 +69,38 @@ func TestSymbolFromCodeKeepsBShareMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestClassRulesFileMergesOverBuiltin(t *testing.T) {
+	rules, err := ParseClassRules(strings.NewReader(`
+# 覆盖内置 + 新增号段
+sh,600,unknown
+sz,399,index,1000
+sh,59,etf
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetClassRules(rules)
+	t.Cleanup(func() { SetClassRules(nil) })
+
+	if got := ClassifyCode("sh600000"); got != ClassUnknown {
+		t.Fatalf("override: got %q", got)
+	}
+	if got := ClassifyCode("sh590001"); got != ClassETF {
+		t.Fatalf("new prefix: got %q", got)
+	}
+	if got := PriceScale("sz399001"); got != 1000 {
+		t.Fatalf("explicit scale: got %v", got)
+	}
+	if got := ClassifyCode("sz000001"); got != ClassStock {
+		t.Fatalf("builtin kept: got %q", got)
+	}
+}
+
+func TestParseClassRulesRejectsBadLines(t *testing.T) {
+	for _, line := range []string{"hk,00,stock", "sh,6a,stock", "sh,600,bond", "sh,600,stock,-1", "sh,600"} {
+		if _, err := ParseClassRules(strings.NewReader(line)); err == nil {
+			t.Fatalf("expected error for %q", line)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// GbbqCategoryNames 是 gbbq category 编号对应的通达信事件名称。
+var GbbqCategoryNames = map[int]string{
+	1:  "除权除息",
+	2:  "送配股上市",
+	3:  "非流通股上市",
+	4:  "未知股本变动",
+	5:  "股本变化",
+	6:  "增发新股",
+	7:  "回购",
+	8:  "增发新股上市",
+	9:  "转配股上市",
+	10: "可转债上市",
+	11: "扩缩股",
+	12: "非流通股缩股",
+	13: "送认购权证",
+	14: "送认沽权证",
+}
+
+// GbbqCategoryName 返回 category 的中文名称，未收录的编号返回 "未知类别 N"。
+func GbbqCategoryName(category int) string {
+	if name, ok := GbbqCategoryNames[category]; ok {
+		return name
+	}
+	return fmt.Sprintf("未知类别 %d", category)
+}
+
+// IsShareCategory 判断 category 是否为股本变动记录。这类记录的
+// C1/C2 为变动前流通股 / 总股本，C3/C4 为变动后流通股 / 总股本，单位万股。
+func IsShareCategory(category int) bool {
+	switch category {
+	case 2, 3, 5, 7, 8, 9, 10:
+		return true
+	}
+	return false
+}
+
+// ShareCapitalFromGbbq 把 gbbq 股本变动记录转成 ShareCapital，股本换算为股。
+func ShareCapitalFromGbbq(data []GbbqData) []ShareCapital {
+	var out []ShareCapital
+	for _, g := range data {
+		if !IsShareCategory(g.Category) {
+			continue
+		}
+		out = append(out, ShareCapital{
+			Symbol:            g.Symbol,
+			Date:              g.Date,
+			Category:          g.Category,
+			CategoryName:      GbbqCategoryName(g.Category),
+			FloatSharesBefore: math.Round(g.C1 * 10000),
+			TotalSharesBefore: math.Round(g.C2 * 10000),
+			FloatSharesAfter:  math.Round(g.C3 * 10000),
+			TotalSharesAfter:  math.Round(g.C4 * 10000),
+		})
+	}
+	return out
+}
+
+// Dividend.EventType 的取值。除权除息事件可能同时包含多项，以 "+" 连接，如 "cash+bonus"。
+const (
+	DividendEventCash   = "cash"   // 派现
+	DividendEventBonus  = "bonus"  // 送转股
+	DividendEventRights = "rights" // 配股
+	DividendEventSplit  = "split"  // ETF / LOF 份额折算
+)
+
+// DividendsFromGbbq 把 gbbq 中 category=1 / 11 的记录转成具名的 Dividend。
+// category=1 字段顺序: C1=每 10 股派现, C2=配股价, C3=每 10 股送转股, C4=每 10 股配股；
+// category=11 的 C3 为份额折算系数。
+func DividendsFromGbbq(data []GbbqData) []Dividend {
+	var out []Dividend
+	for _, g := range data {
+		switch g.Category {
+		case 1:
+			d := Dividend{
+				Symbol:        g.Symbol,
+				ExDate:        g.Date,
+				CashPerShare:  roundPerShare(g.C1 / 10),
+				BonusPerShare: roundPerShare(g.C3 / 10),
+				RightsRatio:   roundPerShare(g.C4 / 10),
+			}
+			var types []string
+			if g.C1 > 0 {
+				types = append(types, DividendEventCash)
+			}
+			if g.C3 > 0 {
+				types = append(types, DividendEventBonus)
+			}
+			if g.C4 > 0 {
+				types = append(types, DividendEventRights)
+				d.RightsPrice = g.C2
+			}
+			if len(types) == 0 {
+				continue
+			}
+			d.EventType = strings.Join(types, "+")
+			out = append(out, d)
+		case 11:
+			if g.C3 <= 0 {
+				continue
+			}
+			out = append(out, Dividend{
+				Symbol:     g.Symbol,
+				ExDate:     g.Date,
+				EventType:  DividendEventSplit,
+				SplitRatio: g.C3,
+			})
+		}
+	}
+	return out
+}
+
+// roundPerShare 去掉除以 10 带来的浮点尾数，保留 6 位小数。
+func roundPerShare(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
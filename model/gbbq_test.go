@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDividendsFromGbbq(t *testing.T) {
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	data := []GbbqData{
+		{Category: 1, Symbol: "sh600000", Date: day, C1: 3.2, C3: 4},
+		{Category: 1, Symbol: "sh600001", Date: day, C2: 8.5, C4: 3},
+		{Category: 1, Symbol: "sh600002", Date: day}, // 空记录
+		{Category: 5, Symbol: "sh600000", Date: day, C3: 100, C4: 200},
+		{Category: 11, Symbol: "sh510300", Date: day, C3: 2},
+	}
+
+	got := DividendsFromGbbq(data)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 dividends, got %d: %+v", len(got), got)
+	}
+	if got[0].EventType != "cash+bonus" || got[0].CashPerShare != 0.32 || got[0].BonusPerShare != 0.4 {
+		t.Fatalf("unexpected cash+bonus row: %+v", got[0])
+	}
+	if got[1].EventType != "rights" || got[1].RightsRatio != 0.3 || got[1].RightsPrice != 8.5 {
+		t.Fatalf("unexpected rights row: %+v", got[1])
+	}
+	if got[2].EventType != "split" || got[2].SplitRatio != 2 {
+		t.Fatalf("unexpected split row: %+v", got[2])
+	}
+}
+
+func TestShareCapitalFromGbbq(t *testing.T) {
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	data := []GbbqData{
+		{Category: 1, Symbol: "sh600000", Date: day, C1: 3},
+		{Category: 5, Symbol: "sh600000", Date: day, C1: 100, C2: 200, C3: 150, C4: 250.5},
+	}
+
+	got := ShareCapitalFromGbbq(data)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 share change, got %d", len(got))
+	}
+	r := got[0]
+	if r.CategoryName != "股本变化" || r.FloatSharesBefore != 1000000 || r.TotalSharesAfter != 2505000 {
+		t.Fatalf("unexpected share change: %+v", r)
+	}
+	if GbbqCategoryName(99) != "未知类别 99" {
+		t.Fatalf("unexpected fallback name %q", GbbqCategoryName(99))
+	}
+}
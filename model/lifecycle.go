@@ -0,0 +1,99 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// 证券生命周期状态。
+const (
+	StatusListed    = "listed"
+	StatusSuspended = "suspended"
+	StatusDelisted  = "delisted"
+)
+
+// UpdateSymbolLifecycle 合并上一轮的生命周期记录、日线起止日期与本轮在线代码表，按 symbol 排序返回。
+//
+// ranges 只需 Symbol / FirstDate / LastDate；online 为 nil 表示本轮没有取到在线代码表，
+// 沿用上一轮的 LastSeenOnline / DroppedDate。状态判定：
+//
+//	delisted  在线代码表覆盖该类别，但最近一次抓取没有返回该代码
+//	suspended 最后交易日早于全市场最新交易日
+//	listed    其余 (含已出现在代码表、尚无日线的新股)
+//
+// 从未成功抓取过在线代码表的类别 (如板块指数) 只能按日线判断，已退市的证券会显示为 suspended。
+func UpdateSymbolLifecycle(prev, ranges []SymbolLifecycle, online []SymbolName, today time.Time) []SymbolLifecycle {
+	today = lifecycleDate(today)
+	rows := make(map[string]*SymbolLifecycle, len(prev)+len(ranges))
+	get := func(symbol, class string) *SymbolLifecycle {
+		r := rows[symbol]
+		if r == nil {
+			r = &SymbolLifecycle{Symbol: symbol, Class: class}
+			rows[symbol] = r
+		}
+		return r
+	}
+
+	for _, p := range prev {
+		r := p
+		rows[p.Symbol] = &r
+	}
+	for _, k := range ranges {
+		r := get(k.Symbol, ClassifyCode(k.Symbol))
+		r.Class = ClassifyCode(k.Symbol)
+		r.FirstDate, r.LastDate = k.FirstDate, k.LastDate
+	}
+
+	if online != nil {
+		seen := make(map[string]bool, len(online))
+		for _, n := range online {
+			r := get(n.Symbol, n.Class)
+			d := today
+			r.LastSeenOnline, r.DroppedDate = &d, nil
+			seen[n.Symbol] = true
+		}
+		for symbol, r := range rows {
+			if !seen[symbol] && r.LastSeenOnline != nil && r.DroppedDate == nil {
+				d := today
+				r.DroppedDate = &d
+			}
+		}
+	}
+
+	var latestTrade, latestSeen time.Time
+	for _, r := range rows {
+		if r.LastDate != nil && lifecycleDate(*r.LastDate).After(latestTrade) {
+			latestTrade = lifecycleDate(*r.LastDate)
+		}
+		if r.LastSeenOnline != nil && lifecycleDate(*r.LastSeenOnline).After(latestSeen) {
+			latestSeen = lifecycleDate(*r.LastSeenOnline)
+		}
+	}
+	// 最近一次抓取返回过的类别才认为受在线代码表覆盖。
+	tracked := make(map[string]bool)
+	for _, r := range rows {
+		if r.LastSeenOnline != nil && lifecycleDate(*r.LastSeenOnline).Equal(latestSeen) {
+			tracked[r.Class] = true
+		}
+	}
+
+	out := make([]SymbolLifecycle, 0, len(rows))
+	for _, r := range rows {
+		switch {
+		case tracked[r.Class] && (r.LastSeenOnline == nil || lifecycleDate(*r.LastSeenOnline).Before(latestSeen)):
+			r.Status = StatusDelisted
+		case r.LastDate != nil && lifecycleDate(*r.LastDate).Before(latestTrade):
+			r.Status = StatusSuspended
+		default:
+			r.Status = StatusListed
+		}
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
+
+// lifecycleDate 把日期规整为 UTC 零点，避免不同 driver 返回的时区影响比较。
+func lifecycleDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
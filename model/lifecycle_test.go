@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateSymbolLifecycle(t *testing.T) {
+	d := func(day int) *time.Time {
+		v := time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+		return &v
+	}
+	ranges := []SymbolLifecycle{
+		{Symbol: "sh600000", FirstDate: d(1), LastDate: d(8)},
+		{Symbol: "sh600001", FirstDate: d(1), LastDate: d(5)},
+		{Symbol: "sh600002", FirstDate: d(1), LastDate: d(4)},
+		{Symbol: "sh880471", FirstDate: d(1), LastDate: d(6)},
+	}
+	online := []SymbolName{
+		{Symbol: "sh600000", Class: ClassStock},
+		{Symbol: "sh600001", Class: ClassStock},
+		{Symbol: "sh600002", Class: ClassStock},
+		{Symbol: "sh688999", Class: ClassStock}, // 新股，尚无日线
+	}
+
+	first := UpdateSymbolLifecycle(nil, ranges, online, *d(8))
+	byFirst := lifecycleBySymbol(first)
+	if len(first) != 5 {
+		t.Fatalf("expected 5 rows, got %d: %+v", len(first), first)
+	}
+	if r := byFirst["sh600000"]; r.Status != StatusListed || r.Class != ClassStock || !r.LastSeenOnline.Equal(*d(8)) {
+		t.Fatalf("unexpected sh600000: %+v", r)
+	}
+	if r := byFirst["sh600001"]; r.Status != StatusSuspended {
+		t.Fatalf("sh600001 should be suspended: %+v", r)
+	}
+	if r := byFirst["sh688999"]; r.Status != StatusListed || r.FirstDate != nil {
+		t.Fatalf("unexpected new listing: %+v", r)
+	}
+	// 板块不在在线代码表中，只按日线判断。
+	if r := byFirst["sh880471"]; r.Status != StatusSuspended || r.LastSeenOnline != nil {
+		t.Fatalf("unexpected block: %+v", r)
+	}
+
+	// 第二轮 sh600002 不再出现在代码表中。
+	second := UpdateSymbolLifecycle(first, ranges, online[:2], *d(9))
+	r := lifecycleBySymbol(second)["sh600002"]
+	if r.Status != StatusDelisted || r.DroppedDate == nil || !r.DroppedDate.Equal(*d(9)) || !r.LastSeenOnline.Equal(*d(8)) {
+		t.Fatalf("sh600002 should be delisted on day 9: %+v", r)
+	}
+
+	// 第三轮没有取到在线代码表，沿用上一轮的跟踪字段。
+	third := UpdateSymbolLifecycle(second, ranges, nil, *d(10))
+	r = lifecycleBySymbol(third)["sh600002"]
+	if r.Status != StatusDelisted || !r.DroppedDate.Equal(*d(9)) {
+		t.Fatalf("dropped date should be kept: %+v", r)
+	}
+	if r := lifecycleBySymbol(third)["sh600000"]; r.Status != StatusListed || !r.LastSeenOnline.Equal(*d(9)) {
+		t.Fatalf("unexpected sh600000 after offline run: %+v", r)
+	}
+}
+
+func lifecycleBySymbol(rows []SymbolLifecycle) map[string]SymbolLifecycle {
+	m := make(map[string]SymbolLifecycle, len(rows))
+	for _, r := range rows {
+		m[r.Symbol] = r
+	}
+	return m
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cond 是 RowQuery 的一个过滤条件。Column 必须来自代码里的白名单，
+// 不能直接取自用户输入；Value 走占位符。
+type Cond struct {
+	Column string
+	Op     string // = >= <= > < LIKE IN
+	Value  any    // Op 为 IN 时传 []string
+}
+
+// RowQuery 描述一次只读查询：表 / 视图 + 过滤 + 分组 + 排序 + 分页。
+// DuckDB 与 ClickHouse 都接受 ? 占位符，两个 driver 共用 SQL 生成。
+type RowQuery struct {
+	Table   string
+	Columns []string // 空表示 *
+	Where   []Cond
+	GroupBy []string
+	OrderBy []string
+	Limit   int // 0 表示不限
+	Offset  int
+}
+
+// RowSet 是 RowQuery 的结果，保留列顺序以便输出 CSV。
+type RowSet struct {
+	Columns []string
+	Rows    [][]any
+}
+
+var queryOps = map[string]bool{"=": true, ">=": true, "<=": true, ">": true, "<": true, "LIKE": true, "IN": true}
+
+// SQL 生成 SELECT 语句与参数。
+func (q RowQuery) SQL() (string, []any, error) {
+	if q.Table == "" {
+		return "", nil, fmt.Errorf("row query: table is required")
+	}
+
+	cols := "*"
+	if len(q.Columns) > 0 {
+		cols = strings.Join(q.Columns, ", ")
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", cols, q.Table)
+
+	var args []any
+	for i, c := range q.Where {
+		if !queryOps[c.Op] {
+			return "", nil, fmt.Errorf("row query: unsupported operator %q", c.Op)
+		}
+		if i == 0 {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		if c.Op != "IN" {
+			fmt.Fprintf(&sb, "%s %s ?", c.Column, c.Op)
+			args = append(args, c.Value)
+			continue
+		}
+		values, ok := c.Value.([]string)
+		if !ok || len(values) == 0 {
+			return "", nil, fmt.Errorf("row query: IN on %s needs a non-empty []string", c.Column)
+		}
+		placeholders := strings.Repeat("?,", len(values))
+		fmt.Fprintf(&sb, "%s IN (%s)", c.Column, placeholders[:len(placeholders)-1])
+		for _, v := range values {
+			args = append(args, v)
+		}
+	}
+
+	if len(q.GroupBy) > 0 {
+		sb.WriteString(" GROUP BY " + strings.Join(q.GroupBy, ", "))
+	}
+	if len(q.OrderBy) > 0 {
+		sb.WriteString(" ORDER BY " + strings.Join(q.OrderBy, ", "))
+	}
+	if q.Limit > 0 {
+		fmt.Fprintf(&sb, " LIMIT %d", q.Limit)
+	}
+	if q.Offset > 0 {
+		fmt.Fprintf(&sb, " OFFSET %d", q.Offset)
+	}
+	return sb.String(), args, nil
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRowQuerySQL(t *testing.T) {
+	q := RowQuery{
+		Table:   "raw_kline_daily",
+		Columns: []string{"symbol", "date", "close"},
+		Where: []Cond{
+			{Column: "symbol", Op: "IN", Value: []string{"sh600000", "sz000001"}},
+			{Column: "date", Op: ">=", Value: "2024-01-02"},
+		},
+		OrderBy: []string{"symbol", "date"},
+		Limit:   11,
+		Offset:  20,
+	}
+	sql, args, err := q.SQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT symbol, date, close FROM raw_kline_daily WHERE symbol IN (?,?) AND date >= ? ORDER BY symbol, date LIMIT 11 OFFSET 20"
+	if sql != want {
+		t.Fatalf("sql = %q\nwant %q", sql, want)
+	}
+	if !reflect.DeepEqual(args, []any{"sh600000", "sz000001", "2024-01-02"}) {
+		t.Fatalf("args = %v", args)
+	}
+
+	if _, _, err := (RowQuery{Table: "t", Where: []Cond{{Column: "a", Op: "; DROP", Value: 1}}}).SQL(); err == nil {
+		t.Fatal("expected error for unsupported operator")
+	}
+
+	sql, _, err = (RowQuery{
+		Table:   "raw_kline_daily",
+		Columns: []string{"symbol", "min(date) AS first_date"},
+		GroupBy: []string{"symbol"},
+		OrderBy: []string{"symbol"},
+	}).SQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT symbol, min(date) AS first_date FROM raw_kline_daily GROUP BY symbol ORDER BY symbol"; sql != want {
+		t.Fatalf("sql = %q\nwant %q", sql, want)
+	}
+}
@@ -1,11 +1,14 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // SchemaMajor 表示数据库 schema 的主版本号。
 // 当发生破坏性变更（表重命名、字段语义变化等）时递增。
 // 已安装的数据库 major 版本与当前代码不匹配时，工具将拒绝操作并提示用户查看文档。
-const SchemaMajor = 5
+const SchemaMajor = 6
 
 // SchemaMinor 表示数据库 schema 的次版本号。
 // 当发生非破坏性变更（新增表、新增字段等）时递增。
@@ -45,7 +48,8 @@ type Factor struct {
 }
 
 // BasicDaily 是每日衍生指标(PreClose/涨跌幅/振幅/换手率/市值)。
-// 覆盖 stock + etf 两类 symbol。
+// 覆盖 stock / bstock / etf / index / block；B 股市值按交易币种 (USD / HKD) 计，
+// 指数与板块只有 PreClose / 涨跌幅 / 振幅。
 type BasicDaily struct {
 	Date          time.Time `col:"date" type:"date"`
 	Symbol        string    `col:"symbol"`
@@ -68,10 +72,45 @@ type GbbqData struct {
 	C4       float64   `col:"c4"`
 }
 
+// Dividend 是 gbbq 除权除息 (category=1) 与份额折算 (category=11) 的具名形式。
+// gbbq 按每 10 股记录，这里统一折算为每股。
+type Dividend struct {
+	Symbol        string    `col:"symbol"`
+	ExDate        time.Time `col:"ex_date" type:"date"`
+	EventType     string    `col:"event_type"`
+	CashPerShare  float64   `col:"cash_per_share"`  // 每股派现 (元，税前)
+	BonusPerShare float64   `col:"bonus_per_share"` // 每股送转股 (送股与转增合计，gbbq 不区分)
+	RightsRatio   float64   `col:"rights_ratio"`    // 每股配股数
+	RightsPrice   float64   `col:"rights_price"`    // 配股价 (元)
+	SplitRatio    float64   `col:"split_ratio"`     // 份额折算系数 (新份额 / 旧份额)，仅 split 事件
+}
+
+// ShareCapital 是 gbbq 股本变动记录 (见 IsShareCategory) 的具名形式，股本单位为股。
+type ShareCapital struct {
+	Symbol            string    `col:"symbol"`
+	Date              time.Time `col:"date" type:"date"`
+	Category          int       `col:"category"`
+	CategoryName      string    `col:"category_name"`
+	FloatSharesBefore float64   `col:"float_shares_before"`
+	TotalSharesBefore float64   `col:"total_shares_before"`
+	FloatSharesAfter  float64   `col:"float_shares_after"`
+	TotalSharesAfter  float64   `col:"total_shares_after"`
+}
+
 type Holiday struct {
 	Date time.Time `col:"date" type:"date"`
 }
 
+// TradeCalendar 是物化的交易日历，每个自然日一行。IsTradingDay 为 0 / 1；
+// TradeSeq 为交易日序号，非交易日沿用之前最近交易日的序号；上 / 下一交易日均不含当天。
+type TradeCalendar struct {
+	Date           time.Time  `col:"date" type:"date"`
+	IsTradingDay   int        `col:"is_trading_day"`
+	TradeSeq       int        `col:"trade_seq"`
+	PrevTradingDay *time.Time `col:"prev_trading_day" type:"date" nullable:"true"`
+	NextTradingDay time.Time  `col:"next_trading_day" type:"date"`
+}
+
 type BlockInfo struct {
 	BlockType   string `col:"block_type"`
 	BlockName   string `col:"block_name"`
@@ -86,12 +125,193 @@ type BlockMember struct {
 	BlockCode   string `col:"block_code"`
 }
 
+// BlockStatsDaily 是按当前成分股算出的板块每日表现，收益率为百分数。
+// 成分股按当日有 basic 记录的计 (停牌不计入)；流通市值加权用前一日流通市值
+// (floatmv / (1 + change_pct/100)) 作权重，全部成分缺少市值时为 NULL。
+type BlockStatsDaily struct {
+	Date           time.Time `col:"date" type:"date"`
+	BlockCode      string    `col:"block_code"`
+	MemberCount    int       `col:"member_count"`
+	UpCount        int       `col:"up_count"`
+	DownCount      int       `col:"down_count"`
+	EqualReturn    float64   `col:"equal_return"`
+	FloatCapReturn *float64  `col:"floatcap_return" nullable:"true"`
+	FloatMV        float64   `col:"floatmv"`
+}
+
 type SymbolName struct {
 	Symbol string `col:"symbol"`
 	Name   string `col:"name"`
 	Class  string `col:"class"`
 }
 
+// CBondUnderlying 是可转债与正股的对应关系，由 MatchCBondUnderlying 按名称推断。
+type CBondUnderlying struct {
+	Symbol      string `col:"symbol"`
+	StockSymbol string `col:"stock_symbol"`
+}
+
+// SymbolLifecycle 是一只证券的上市生命周期。FirstDate / LastDate 取自 raw_kline_daily，
+// 没有日线时为 NULL；LastSeenOnline 为在线代码表最近一次返回该代码的日期，
+// DroppedDate 为在线代码表首次不再返回该代码的日期 (开始跟踪前已退市的为 NULL)。
+type SymbolLifecycle struct {
+	Symbol         string     `col:"symbol"`
+	Class          string     `col:"class"`
+	FirstDate      *time.Time `col:"first_date" type:"date" nullable:"true"`
+	LastDate       *time.Time `col:"last_date" type:"date" nullable:"true"`
+	Status         string     `col:"status"`
+	LastSeenOnline *time.Time `col:"last_seen_online" type:"date" nullable:"true"`
+	DroppedDate    *time.Time `col:"dropped_date" type:"date" nullable:"true"`
+}
+
+// SymbolAlias 记录代码变更：EffectiveDate 起 OldSymbol 的行情由 NewSymbol 延续。
+// Ratio 为每股旧股换得的新股数 (代码平移为 1)；Source 为 user (用户导入) 或 tdx (按通达信数据推断)。
+type SymbolAlias struct {
+	OldSymbol     string    `col:"old_symbol"`
+	NewSymbol     string    `col:"new_symbol"`
+	EffectiveDate time.Time `col:"effective_date" type:"date"`
+	Ratio         float64   `col:"ratio"`
+	Source        string    `col:"source"`
+}
+
+// StitchedDaily 是按 raw_symbol_alias 拼接后的连续序列：Symbol 为最终代码，
+// SourceSymbol 为当日行情实际所在的代码。拼接日的 PreClose / 涨跌幅 / 振幅按旧代码收盘价重算，
+// HfqFactor 在整条拼接序列上重新计算。
+type StitchedDaily struct {
+	Symbol        string    `col:"symbol"`
+	Date          time.Time `col:"date" type:"date"`
+	SourceSymbol  string    `col:"source_symbol"`
+	PreClose      float64   `col:"preclose"`
+	ChangePercent float64   `col:"change_pct"`
+	Amplitude     float64   `col:"amplitude"`
+	HfqFactor     float64   `col:"hfq_factor"`
+}
+
+// KlineDailyPatch 标记 raw_kline_daily 中由在线行情临时补齐的日期。
+// 下次 g4day 官方数据导入这些日期时，先删掉对应行与标记再导入。
+type KlineDailyPatch struct {
+	Date     time.Time `col:"date" type:"date"`
+	Source   string    `col:"source"`
+	FilledAt time.Time `col:"filled_at" type:"datetime"`
+}
+
+// QuoteSnapshot 是一次实时行情快照。成交量与挂单量单位为股，
+// 同一次抓取的全部行共用 SnapshotTime。
+type QuoteSnapshot struct {
+	SnapshotTime time.Time `col:"snapshot_time" type:"datetime"`
+	Symbol       string    `col:"symbol"`
+	Price        float64   `col:"price"`
+	PreClose     float64   `col:"preclose"`
+	Open         float64   `col:"open"`
+	High         float64   `col:"high"`
+	Low          float64   `col:"low"`
+	Volume       int64     `col:"volume"`
+	Amount       float64   `col:"amount"`
+	Bid1         float64   `col:"bid1"`
+	Bid2         float64   `col:"bid2"`
+	Bid3         float64   `col:"bid3"`
+	Bid4         float64   `col:"bid4"`
+	Bid5         float64   `col:"bid5"`
+	Ask1         float64   `col:"ask1"`
+	Ask2         float64   `col:"ask2"`
+	Ask3         float64   `col:"ask3"`
+	Ask4         float64   `col:"ask4"`
+	Ask5         float64   `col:"ask5"`
+	BidVol1      int64     `col:"bid_vol1"`
+	BidVol2      int64     `col:"bid_vol2"`
+	BidVol3      int64     `col:"bid_vol3"`
+	BidVol4      int64     `col:"bid_vol4"`
+	BidVol5      int64     `col:"bid_vol5"`
+	AskVol1      int64     `col:"ask_vol1"`
+	AskVol2      int64     `col:"ask_vol2"`
+	AskVol3      int64     `col:"ask_vol3"`
+	AskVol4      int64     `col:"ask_vol4"`
+	AskVol5      int64     `col:"ask_vol5"`
+}
+
+// FinanceFieldCount 是 raw_finance 保留的字段数 (f001 ~ f600)。
+// 通达信 gpcw 各期文件字段数不同，早年文件缺少的字段以 NULL 入库。
+const FinanceFieldCount = 600
+
+// Finance 是一只证券一期财报的全部数值字段，编号与通达信 gpcw 一致 (Fields[0] 为 f001)。
+// 前 N 个字段有值，其余为 NULL；AnnounceDate 为零值时表示公告日期未知。
+type Finance struct {
+	Symbol       string
+	ReportDate   time.Time
+	AnnounceDate time.Time
+	N            int
+	Fields       [FinanceFieldCount]float32
+}
+
+// FinanceFieldColumn 返回第 n 个字段 (从 1 开始) 的列名，如 f001。
+func FinanceFieldColumn(n int) string {
+	return fmt.Sprintf("f%03d", n)
+}
+
+func financeColumns() []Column {
+	cols := []Column{
+		{Name: "symbol", Type: TypeString},
+		{Name: "report_date", Type: TypeDate},
+		{Name: "announce_date", Type: TypeDate, Nullable: true},
+	}
+	for i := 1; i <= FinanceFieldCount; i++ {
+		cols = append(cols, Column{Name: FinanceFieldColumn(i), Type: TypeFloat64, Nullable: true})
+	}
+	return cols
+}
+
+// FinanceFile 记录已导入的 gpcw 文件及其 hash，hash 变化时重新下载。
+type FinanceFile struct {
+	ReportDate time.Time `col:"report_date" type:"date"`
+	Filename   string    `col:"filename"`
+	Hash       string    `col:"hash"`
+	Size       int64     `col:"size"`
+	ImportedAt time.Time `col:"imported_at" type:"datetime"`
+}
+
+// ValuationDaily 是个股每个交易日的估值指标。分母为 0 或缺少财报时估值为 NULL；
+// PE / PS 为倍数，股息率为百分数。
+type ValuationDaily struct {
+	Date             time.Time `col:"date" type:"date"`
+	Symbol           string    `col:"symbol"`
+	TotalMV          float64   `col:"totalmv"`
+	PETTM            *float64  `col:"pe_ttm" nullable:"true"`
+	PEStatic         *float64  `col:"pe_static" nullable:"true"`
+	PB               *float64  `col:"pb" nullable:"true"`
+	PSTTM            *float64  `col:"ps_ttm" nullable:"true"`
+	DividendYieldTTM float64   `col:"dv_ttm"`
+}
+
+// ReturnsDaily 是按后复权价格计算的每日收益率 (小数，非百分比)，窗口按交易日计。
+// Ret* 为截至当日收盘的回看收益，LogRet1 为日对数收益；FwdRet*Open / FwdRet*Close
+// 为下一交易日开盘 / 收盘买入、持有 N 个交易日后同一时点卖出的前瞻收益；
+// Excess* 为相对 Benchmark 指数同窗口收益的超额。数据不足或无法成交时为 NULL。
+type ReturnsDaily struct {
+	Date                time.Time `col:"date" type:"date"`
+	Symbol              string    `col:"symbol"`
+	Ret1                *float64  `col:"ret_1" nullable:"true"`
+	Ret5                *float64  `col:"ret_5" nullable:"true"`
+	Ret10               *float64  `col:"ret_10" nullable:"true"`
+	Ret20               *float64  `col:"ret_20" nullable:"true"`
+	Ret60               *float64  `col:"ret_60" nullable:"true"`
+	LogRet1             *float64  `col:"log_ret_1" nullable:"true"`
+	FwdRet1Open         *float64  `col:"fwd_ret_1_open" nullable:"true"`
+	FwdRet5Open         *float64  `col:"fwd_ret_5_open" nullable:"true"`
+	FwdRet20Open        *float64  `col:"fwd_ret_20_open" nullable:"true"`
+	FwdRet1Close        *float64  `col:"fwd_ret_1_close" nullable:"true"`
+	FwdRet5Close        *float64  `col:"fwd_ret_5_close" nullable:"true"`
+	FwdRet20Close       *float64  `col:"fwd_ret_20_close" nullable:"true"`
+	Benchmark           string    `col:"benchmark"`
+	ExcessRet1          *float64  `col:"excess_ret_1" nullable:"true"`
+	ExcessRet5          *float64  `col:"excess_ret_5" nullable:"true"`
+	ExcessRet10         *float64  `col:"excess_ret_10" nullable:"true"`
+	ExcessRet20         *float64  `col:"excess_ret_20" nullable:"true"`
+	ExcessRet60         *float64  `col:"excess_ret_60" nullable:"true"`
+	FwdExcessRet1Close  *float64  `col:"fwd_excess_ret_1_close" nullable:"true"`
+	FwdExcessRet5Close  *float64  `col:"fwd_excess_ret_5_close" nullable:"true"`
+	FwdExcessRet20Close *float64  `col:"fwd_excess_ret_20_close" nullable:"true"`
+}
+
 type Meta struct {
 	Key   string `col:"key"`
 	Value string `col:"value"`
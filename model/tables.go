@@ -78,7 +78,12 @@ func SchemaFromStruct(tableName string, model interface{}, orderByKey []string)
 		case customType == "datetime":
 			dType = TypeDateTime
 		default:
-			switch field.Type.Kind() {
+			// 指针字段 (*float64 等) 按元素类型建列，NULL 由 nullable 标签声明。
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			switch ft.Kind() {
 			case reflect.String:
 				dType = TypeString
 			case reflect.Float64, reflect.Float32:
@@ -86,7 +91,7 @@ func SchemaFromStruct(tableName string, model interface{}, orderByKey []string)
 			case reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint32:
 				dType = TypeInt64
 			case reflect.Struct:
-				if field.Type == reflect.TypeOf(time.Time{}) {
+				if ft == reflect.TypeOf(time.Time{}) {
 					dType = TypeDateTime
 				}
 			default:
@@ -110,6 +115,17 @@ func SchemaFromStruct(tableName string, model interface{}, orderByKey []string)
 	return meta
 }
 
+// DefineTable 注册列无法用 struct 表达的表 (如字段编号展开的宽表)。
+func DefineTable(tableName string, cols []Column, orderByKey []string) *TableMeta {
+	meta := &TableMeta{
+		TableName:  tableName,
+		Columns:    cols,
+		OrderByKey: orderByKey,
+	}
+	registerTable(meta)
+	return meta
+}
+
 var MetaTable = SchemaFromStruct(
 	"_meta",
 	Meta{},
@@ -128,6 +144,12 @@ var TableKline1Min = SchemaFromStruct(
 	[]string{"symbol", "datetime"},
 )
 
+var TableKlineDailyPatch = SchemaFromStruct(
+	"raw_kline_daily_patch",
+	KlineDailyPatch{},
+	[]string{"date"},
+)
+
 var TableSymbolClass = SchemaFromStruct(
 	"raw_symbol_class",
 	SymbolClass{},
@@ -152,12 +174,30 @@ var TableBasicDaily = SchemaFromStruct(
 	[]string{"symbol", "date"},
 )
 
+var TableDividend = SchemaFromStruct(
+	"raw_dividend",
+	Dividend{},
+	[]string{"symbol", "ex_date"},
+)
+
+var TableShareCapital = SchemaFromStruct(
+	"raw_share_capital",
+	ShareCapital{},
+	[]string{"symbol", "date"},
+)
+
 var TableHoliday = SchemaFromStruct(
 	"raw_holidays",
 	Holiday{},
 	[]string{""},
 )
 
+var TableTradeCalendar = SchemaFromStruct(
+	"raw_trade_calendar",
+	TradeCalendar{},
+	[]string{"date"},
+)
+
 var TableBlockInfo = SchemaFromStruct(
 	"raw_tdx_blocks_info",
 	BlockInfo{},
@@ -175,3 +215,63 @@ var TableSymbolName = SchemaFromStruct(
 	SymbolName{},
 	[]string{"symbol"},
 )
+
+var TableCBondUnderlying = SchemaFromStruct(
+	"raw_cbond_underlying",
+	CBondUnderlying{},
+	[]string{"symbol"},
+)
+
+var TableSymbolLifecycle = SchemaFromStruct(
+	"raw_symbol_lifecycle",
+	SymbolLifecycle{},
+	[]string{"symbol"},
+)
+
+var TableSymbolAlias = SchemaFromStruct(
+	"raw_symbol_alias",
+	SymbolAlias{},
+	[]string{"old_symbol"},
+)
+
+var TableStitchedDaily = SchemaFromStruct(
+	"raw_stitched_daily",
+	StitchedDaily{},
+	[]string{"symbol", "date"},
+)
+
+var TableQuoteSnapshot = SchemaFromStruct(
+	"raw_quote_snapshot",
+	QuoteSnapshot{},
+	[]string{"symbol", "snapshot_time"},
+)
+
+var TableFinance = DefineTable(
+	"raw_finance",
+	financeColumns(),
+	[]string{"symbol", "report_date"},
+)
+
+var TableFinanceFile = SchemaFromStruct(
+	"raw_finance_files",
+	FinanceFile{},
+	[]string{"report_date"},
+)
+
+var TableValuationDaily = SchemaFromStruct(
+	"raw_valuation_daily",
+	ValuationDaily{},
+	[]string{"symbol", "date"},
+)
+
+var TableReturnsDaily = SchemaFromStruct(
+	"raw_returns_daily",
+	ReturnsDaily{},
+	[]string{"symbol", "date"},
+)
+
+var TableBlockStatsDaily = SchemaFromStruct(
+	"raw_block_stats_daily",
+	BlockStatsDaily{},
+	[]string{"block_code", "date"},
+)
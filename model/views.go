@@ -2,7 +2,10 @@ package model
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // ViewDef 描述一个数据库视图：名字 + 每种方言的 SELECT 主体 SQL。
@@ -41,18 +44,66 @@ func AllViews() []ViewDef {
 // --- 定义视图 ---
 //
 // 命名约定：v_<class>_<fq>，class 放前面便于 tab-complete 按归属浏览
-// （v_stock_<TAB> / v_etf_<TAB> 各列出 3 个）。
-// stock / etf 拆开维护：ETF 价格 scale=1000、ROUND 精度 3 位，
-// stock scale=100、ROUND 精度 2 位。
+// （v_stock_<TAB> / v_etf_<TAB> / v_bstock_<TAB> 列出各自的复权视图）。
+// stock / etf / bstock 拆开维护：ETF 与 B 股价格 scale=1000、ROUND 精度 3 位，
+// stock scale=100、ROUND 精度 2 位。B 股视图额外带 currency 列。
 var (
-	ViewStockBFQ = DefineView(stockBFQView("v_stock_bfq", "stock"))
-	ViewStockQFQ = DefineView(adjustedQFQView("v_stock_qfq", "stock", 2))
-	ViewStockHFQ = DefineView(adjustedHFQView("v_stock_hfq", "stock", 2))
-	ViewETFBFQ   = DefineView(stockBFQView("v_etf_bfq", "etf"))
-	ViewETFQFQ   = DefineView(adjustedQFQView("v_etf_qfq", "etf", 3))
-	ViewETFHFQ   = DefineView(adjustedHFQView("v_etf_hfq", "etf", 3))
+	ViewStockBFQ = DefineView(stockBFQView("v_stock_bfq", ClassStock))
+	ViewStockQFQ = DefineView(adjustedQFQView("v_stock_qfq", ClassStock, PricePrecision(ClassStock)))
+	ViewStockHFQ = DefineView(adjustedHFQView("v_stock_hfq", ClassStock, PricePrecision(ClassStock)))
+	ViewETFBFQ   = DefineView(stockBFQView("v_etf_bfq", ClassETF))
+	ViewETFQFQ   = DefineView(adjustedQFQView("v_etf_qfq", ClassETF, PricePrecision(ClassETF)))
+	ViewETFHFQ   = DefineView(adjustedHFQView("v_etf_hfq", ClassETF, PricePrecision(ClassETF)))
+
+	ViewBStockBFQ = DefineView(withCurrency(stockBFQView("v_bstock_bfq", ClassBStock)))
+	ViewBStockQFQ = DefineView(withCurrency(adjustedQFQView("v_bstock_qfq", ClassBStock, PricePrecision(ClassBStock))))
+	ViewBStockHFQ = DefineView(withCurrency(adjustedHFQView("v_bstock_hfq", ClassBStock, PricePrecision(ClassBStock))))
 )
 
+// PricePrecision 返回价格 ROUND 的小数位：价格 scale=1000 的品种取 3 位，其余 2 位。
+func PricePrecision(class string) int {
+	switch class {
+	case ClassETF, ClassBStock, ClassCBond:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// DailyBar 是日线视图 (v_<class>_<fq>) 的一行。Factor 为该行所用的复权因子：
+// qfq 视图取 qfq_factor，hfq 视图取 hfq_factor，不复权为 1。
+// basic 缺失时 PreClose / Turnover 等为 0。
+type DailyBar struct {
+	Symbol        string    `col:"symbol"`
+	Date          time.Time `col:"date"`
+	Open          float64   `col:"open"`
+	High          float64   `col:"high"`
+	Low           float64   `col:"low"`
+	Close         float64   `col:"close"`
+	PreClose      float64   `col:"preclose"`
+	Volume        int64     `col:"volume"`
+	Amount        float64   `col:"amount"`
+	Turnover      float64   `col:"turnover"`
+	FloatMV       float64   `col:"floatmv"`
+	TotalMV       float64   `col:"totalmv"`
+	ChangePercent float64   `col:"change_pct"`
+	Amplitude     float64   `col:"amplitude"`
+	Factor        float64   `col:"factor"`
+}
+
+// withCurrency 在日线视图外再套一层，按市场补上计价币种：沪市 B 股美元，深市 B 股港币。
+func withCurrency(view ViewDef) ViewDef {
+	wrap := func(inner string) string {
+		return fmt.Sprintf(`
+		SELECT
+			t.*,
+			CASE WHEN t.symbol LIKE 'sh%%' THEN 'USD' ELSE 'HKD' END AS currency
+		FROM (%s) t
+	`, inner)
+	}
+	return ViewDef{Name: view.Name, DuckDB: wrap(view.DuckDB), ClickHouse: wrap(view.ClickHouse)}
+}
+
 func stockBFQView(name, class string) ViewDef {
 	sql := fmt.Sprintf(`
 		WITH latest_factors AS (
@@ -173,3 +224,434 @@ func adjustedQFQView(name, class string, precision int) ViewDef {
 	)
 	return ViewDef{Name: name, DuckDB: sql, ClickHouse: sql}
 }
+
+// 指数与板块没有复权，只有一个日线视图，附带 basic 阶段算出的前收、涨跌幅与振幅。
+var (
+	ViewIndexDaily = DefineView(indexDailyView())
+	ViewBlockDaily = DefineView(blockDailyView())
+)
+
+// dailyStatsColumns 是不复权日线视图的公共列，与 DailyBar 对齐 (factor 由调用方补)。
+const dailyStatsColumns = `
+			s.symbol     AS symbol,
+			s.date       AS date,
+			s.open       AS open,
+			s.high       AS high,
+			s.low        AS low,
+			s.close      AS close,
+			b.preclose   AS preclose,
+			s.volume     AS volume,
+			s.amount     AS amount,
+			b.turnover   AS turnover,
+			b.floatmv    AS floatmv,
+			b.totalmv    AS totalmv,
+			b.change_pct AS change_pct,
+			b.amplitude  AS amplitude`
+
+func indexDailyView() ViewDef {
+	sql := fmt.Sprintf(`
+		SELECT%s,
+			n.name       AS name
+		FROM %s s
+		INNER JOIN %s sc ON s.symbol = sc.symbol AND sc.class = '%s'
+		LEFT JOIN %s b ON s.symbol = b.symbol AND s.date = b.date
+		LEFT JOIN %s n ON s.symbol = n.symbol
+	`,
+		dailyStatsColumns,
+		TableKlineDaily.TableName,
+		TableSymbolClass.TableName,
+		ClassIndex,
+		TableBasicDaily.TableName,
+		TableSymbolName.TableName,
+	)
+	return ViewDef{Name: "v_index_daily", DuckDB: sql, ClickHouse: sql}
+}
+
+// blockDailyView 按 block_symbol 关联板块信息；同一指数代码对应多个板块时取字典序最小的名称。
+func blockDailyView() ViewDef {
+	sql := fmt.Sprintf(`
+		WITH block_names AS (
+			SELECT block_symbol, min(block_name) AS block_name, min(block_type) AS block_type
+			FROM %s
+			WHERE block_symbol != ''
+			GROUP BY block_symbol
+		)
+		SELECT%s,
+			bi.block_name AS block_name,
+			bi.block_type AS block_type
+		FROM %s s
+		INNER JOIN %s sc ON s.symbol = sc.symbol AND sc.class = '%s'
+		LEFT JOIN %s b ON s.symbol = b.symbol AND s.date = b.date
+		LEFT JOIN block_names bi ON s.symbol = bi.block_symbol
+	`,
+		TableBlockInfo.TableName,
+		dailyStatsColumns,
+		TableKlineDaily.TableName,
+		TableSymbolClass.TableName,
+		ClassBlock,
+		TableBasicDaily.TableName,
+	)
+	return ViewDef{Name: "v_block_daily", DuckDB: sql, ClickHouse: sql}
+}
+
+// dailyViews 记录每个 class 的日线视图 (bfq/qfq/hfq)，serve 与 SDK 按 class 选视图。
+var dailyViews = map[string]map[string]string{
+	ClassStock:  {"bfq": ViewStockBFQ.Name, "qfq": ViewStockQFQ.Name, "hfq": ViewStockHFQ.Name},
+	ClassETF:    {"bfq": ViewETFBFQ.Name, "qfq": ViewETFQFQ.Name, "hfq": ViewETFHFQ.Name},
+	ClassBStock: {"bfq": ViewBStockBFQ.Name, "qfq": ViewBStockQFQ.Name, "hfq": ViewBStockHFQ.Name},
+	ClassIndex:  {"bfq": ViewIndexDaily.Name},
+	ClassBlock:  {"bfq": ViewBlockDaily.Name},
+}
+
+// DailyView 返回 class 在 fq 复权方式下的日线视图名；没有对应视图时 ok=false，
+// 调用方可退回 raw_kline_daily (仅 bfq 有意义)。
+func DailyView(class, fq string) (string, bool) {
+	name, ok := dailyViews[class][fq]
+	return name, ok
+}
+
+// FinanceNamedFields 是 v_finance 暴露的常用财务字段：列名 → gpcw 字段编号。
+// 金额单位为元，股本单位为股，比率为百分数。
+var FinanceNamedFields = []struct {
+	Column string
+	Field  int
+}{
+	{"eps", 1},                   // 基本每股收益
+	{"eps_deducted", 2},          // 扣非每股收益
+	{"undistributed_ps", 3},      // 每股未分配利润
+	{"bps", 4},                   // 每股净资产
+	{"capital_reserve_ps", 5},    // 每股资本公积金
+	{"roe", 6},                   // 净资产收益率
+	{"ocfps", 7},                 // 每股经营现金流量
+	{"total_assets", 40},         // 资产总计
+	{"total_liabilities", 63},    // 负债合计
+	{"equity", 72},               // 所有者权益合计
+	{"revenue", 74},              // 营业收入
+	{"operating_cost", 75},       // 营业成本
+	{"operating_profit", 86},     // 营业利润
+	{"total_profit", 92},         // 利润总额
+	{"net_profit", 95},           // 净利润
+	{"net_profit_parent", 96},    // 归属于母公司所有者的净利润
+	{"operating_cash_flow", 107}, // 经营活动产生的现金流量净额
+	{"total_shares", 238},        // 总股本
+	{"float_a_shares", 239},      // 已上市流通 A 股
+}
+
+// ViewFinance 给 raw_finance 的常用字段起名，其余字段仍按 fNNN 从原表查询。
+var ViewFinance = DefineView(financeView())
+
+func financeView() ViewDef {
+	cols := make([]string, len(FinanceNamedFields))
+	for i, f := range FinanceNamedFields {
+		cols[i] = fmt.Sprintf("%s AS %s", FinanceFieldColumn(f.Field), f.Column)
+	}
+	sql := fmt.Sprintf(`
+		SELECT
+			symbol,
+			report_date,
+			announce_date,
+			%s
+		FROM %s
+	`, strings.Join(cols, ",\n\t\t\t"), TableFinance.TableName)
+	return ViewDef{Name: "v_finance", DuckDB: sql, ClickHouse: sql}
+}
+
+// ViewStockValuation 在 raw_valuation_daily 上补充收盘价与证券名称。
+var ViewStockValuation = DefineView(stockValuationView())
+
+func stockValuationView() ViewDef {
+	sql := fmt.Sprintf(`
+		SELECT
+			v.date      AS date,
+			v.symbol    AS symbol,
+			n.name      AS name,
+			b.close     AS close,
+			v.totalmv   AS totalmv,
+			v.pe_ttm    AS pe_ttm,
+			v.pe_static AS pe_static,
+			v.pb        AS pb,
+			v.ps_ttm    AS ps_ttm,
+			v.dv_ttm    AS dv_ttm
+		FROM %s v
+		LEFT JOIN %s b ON v.symbol = b.symbol AND v.date = b.date
+		LEFT JOIN %s n ON v.symbol = n.symbol
+	`, TableValuationDaily.TableName, TableBasicDaily.TableName, TableSymbolName.TableName)
+	return ViewDef{Name: "v_stock_valuation", DuckDB: sql, ClickHouse: sql}
+}
+
+// ViewGbbqNamed 给 raw_gbbq 补上 category 中文名称；股本变动记录额外给出
+// 变动前后的流通股 / 总股本 (单位股)，其他类别这些列为 NULL。
+var ViewGbbqNamed = DefineView(gbbqNamedView())
+
+func gbbqNamedView() ViewDef {
+	codes := make([]int, 0, len(GbbqCategoryNames))
+	for c := range GbbqCategoryNames {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+
+	var names strings.Builder
+	var shareCodes []string
+	for _, c := range codes {
+		fmt.Fprintf(&names, "\n\t\t\t\tWHEN %d THEN '%s'", c, GbbqCategoryNames[c])
+		if IsShareCategory(c) {
+			shareCodes = append(shareCodes, fmt.Sprint(c))
+		}
+	}
+	isShare := fmt.Sprintf("category IN (%s)", strings.Join(shareCodes, ", "))
+
+	sql := fmt.Sprintf(`
+		SELECT
+			symbol,
+			date,
+			category,
+			CASE category%s
+				ELSE concat('未知类别 ', CAST(category AS VARCHAR))
+			END AS category_name,
+			c1,
+			c2,
+			c3,
+			c4,
+			CASE WHEN %s THEN c1 * 10000 END AS float_shares_before,
+			CASE WHEN %s THEN c2 * 10000 END AS total_shares_before,
+			CASE WHEN %s THEN c3 * 10000 END AS float_shares_after,
+			CASE WHEN %s THEN c4 * 10000 END AS total_shares_after
+		FROM %s
+	`, names.String(), isShare, isShare, isShare, isShare, TableGbbq.TableName)
+	return ViewDef{Name: "v_gbbq_named", DuckDB: sql, ClickHouse: sql}
+}
+
+// ViewBlockStats 在 raw_block_stats_daily 上补充板块名称、类型与对应的通达信板块指数代码。
+var ViewBlockStats = DefineView(blockStatsView())
+
+func blockStatsView() ViewDef {
+	sql := fmt.Sprintf(`
+		SELECT
+			s.date            AS date,
+			s.block_code      AS block_code,
+			i.block_name      AS block_name,
+			i.block_type      AS block_type,
+			i.block_symbol    AS block_symbol,
+			s.member_count    AS member_count,
+			s.up_count        AS up_count,
+			s.down_count      AS down_count,
+			s.equal_return    AS equal_return,
+			s.floatcap_return AS floatcap_return,
+			s.floatmv         AS floatmv
+		FROM %s s
+		LEFT JOIN %s i ON s.block_code = i.block_code
+	`, TableBlockStatsDaily.TableName, TableBlockInfo.TableName)
+	return ViewDef{Name: "v_block_stats_daily", DuckDB: sql, ClickHouse: sql}
+}
+
+// ViewCBondDaily 是可转债日线，附转债名称及正股代码、名称与当日正股收盘价。
+// 可转债没有复权，未能匹配正股的转债 stock_* 列为空。
+var ViewCBondDaily = DefineView(cbondDailyView())
+
+func cbondDailyView() ViewDef {
+	sql := fmt.Sprintf(`
+		SELECT
+			s.symbol       AS symbol,
+			s.date         AS date,
+			s.open         AS open,
+			s.high         AS high,
+			s.low          AS low,
+			s.close        AS close,
+			s.volume       AS volume,
+			s.amount       AS amount,
+			n.name         AS name,
+			u.stock_symbol AS stock_symbol,
+			sn.name        AS stock_name,
+			k.close        AS stock_close
+		FROM %s s
+		INNER JOIN %s sc ON s.symbol = sc.symbol AND sc.class = '%s'
+		LEFT JOIN %s n ON s.symbol = n.symbol
+		LEFT JOIN %s u ON s.symbol = u.symbol
+		LEFT JOIN %s sn ON u.stock_symbol = sn.symbol
+		LEFT JOIN %s k ON u.stock_symbol = k.symbol AND s.date = k.date
+	`,
+		TableKlineDaily.TableName,
+		TableSymbolClass.TableName,
+		ClassCBond,
+		TableSymbolName.TableName,
+		TableCBondUnderlying.TableName,
+		TableSymbolName.TableName,
+		TableKlineDaily.TableName,
+	)
+	return ViewDef{Name: "v_cbond_daily", DuckDB: sql, ClickHouse: sql}
+}
+
+// ViewSymbolUniverse 按交易日展开 raw_symbol_lifecycle：每个交易日列出当日处于上市区间
+// (first_date <= date <= last_date) 的全部证券，含此后退市的，用于无幸存者偏差的回测股票池。
+// 交易日取自 raw_kline_daily 中出现过的日期，使用时按 date 过滤。
+var ViewSymbolUniverse = DefineView(symbolUniverseView())
+
+func symbolUniverseView() ViewDef {
+	sql := fmt.Sprintf(`
+		SELECT
+			d.date         AS date,
+			l.symbol       AS symbol,
+			l.class        AS class,
+			l.first_date   AS first_date,
+			l.last_date    AS last_date,
+			l.status       AS status,
+			l.dropped_date AS dropped_date
+		FROM (SELECT DISTINCT date FROM %s) d
+		CROSS JOIN %s l
+		WHERE d.date >= l.first_date AND d.date <= l.last_date
+	`, TableKlineDaily.TableName, TableSymbolLifecycle.TableName)
+	return ViewDef{Name: "v_symbol_universe", DuckDB: sql, ClickHouse: sql}
+}
+
+// 拼接日线：按 raw_symbol_alias 把旧代码的历史接到新代码下，复权因子取 raw_stitched_daily
+// 在整条序列上重算的结果；没有代码变更的股票与 v_stock_<fq> 相同，旧代码本身不再单独出现。
+var (
+	ViewStockStitchedBFQ = DefineView(stitchedView("v_stock_stitched_bfq", ViewStockBFQ, "bfq", PricePrecision(ClassStock)))
+	ViewStockStitchedQFQ = DefineView(stitchedView("v_stock_stitched_qfq", ViewStockQFQ, "qfq", PricePrecision(ClassStock)))
+	ViewStockStitchedHFQ = DefineView(stitchedView("v_stock_stitched_hfq", ViewStockHFQ, "hfq", PricePrecision(ClassStock)))
+)
+
+func stitchedView(name string, base ViewDef, fq string, precision int) ViewDef {
+	// 价格与末尾因子列与 base 视图保持同样的顺序，才能 UNION ALL。
+	var price func(col string) string
+	var factors string
+	switch fq {
+	case "qfq":
+		price = func(col string) string {
+			return fmt.Sprintf("ROUND(%s * st.hfq_factor / lf.latest_hfq, %d)", col, precision)
+		}
+		factors = "st.hfq_factor / lf.latest_hfq AS qfq_factor"
+	case "hfq":
+		price = func(col string) string {
+			return fmt.Sprintf("ROUND(%s * st.hfq_factor, %d)", col, precision)
+		}
+		factors = "st.hfq_factor AS hfq_factor"
+	default:
+		price = func(col string) string { return col }
+		factors = "st.hfq_factor AS hfq_factor,\n\t\t\tst.hfq_factor / lf.latest_hfq AS qfq_factor"
+	}
+
+	sql := fmt.Sprintf(`
+		WITH latest_stitched AS (
+			SELECT symbol, argMax(hfq_factor, date) AS latest_hfq
+			FROM %s
+			GROUP BY symbol
+		)
+		SELECT
+			st.symbol     AS symbol,
+			st.date       AS date,
+			%s AS open,
+			%s AS high,
+			%s AS low,
+			%s AS close,
+			%s AS preclose,
+			k.volume      AS volume,
+			k.amount      AS amount,
+			b.turnover    AS turnover,
+			b.floatmv     AS floatmv,
+			b.totalmv     AS totalmv,
+			st.change_pct AS change_pct,
+			st.amplitude  AS amplitude,
+			%s
+		FROM %s st
+		INNER JOIN %s sc ON st.symbol = sc.symbol AND sc.class = '%s'
+		INNER JOIN %s k ON st.source_symbol = k.symbol AND st.date = k.date
+		LEFT JOIN latest_stitched lf ON st.symbol = lf.symbol
+		LEFT JOIN %s b ON st.source_symbol = b.symbol AND st.date = b.date
+		UNION ALL
+		SELECT * FROM %s
+		WHERE symbol NOT IN (SELECT source_symbol FROM %s)
+	`,
+		TableStitchedDaily.TableName,
+		price("k.open"), price("k.high"), price("k.low"), price("k.close"), price("st.preclose"),
+		factors,
+		TableStitchedDaily.TableName,
+		TableSymbolClass.TableName,
+		ClassStock,
+		TableKlineDaily.TableName,
+		TableBasicDaily.TableName,
+		base.Name,
+		TableStitchedDaily.TableName,
+	)
+	return ViewDef{Name: name, DuckDB: sql, ClickHouse: sql}
+}
+
+// 停牌补全日线：上市到退市 (未退市的到最新交易日) 之间每个交易日一行。交易日按 raw_holidays
+// 的周末 + 节假日规则枚举；停牌日用 ASOF JOIN 取之前最近一根 K 线，开高低收与前收取其收盘价，
+// 成交量 / 额、换手率、涨跌幅、振幅为 0，市值与复权因子沿用，is_suspended = 1。
+var (
+	ViewStockFilledBFQ = DefineView(filledView("v_stock_filled_bfq", ViewStockBFQ, ClassStock, "hfq_factor", "qfq_factor"))
+	ViewStockFilledQFQ = DefineView(filledView("v_stock_filled_qfq", ViewStockQFQ, ClassStock, "qfq_factor"))
+	ViewStockFilledHFQ = DefineView(filledView("v_stock_filled_hfq", ViewStockHFQ, ClassStock, "hfq_factor"))
+	ViewETFFilledBFQ   = DefineView(filledView("v_etf_filled_bfq", ViewETFBFQ, ClassETF, "hfq_factor", "qfq_factor"))
+	ViewETFFilledQFQ   = DefineView(filledView("v_etf_filled_qfq", ViewETFQFQ, ClassETF, "qfq_factor"))
+	ViewETFFilledHFQ   = DefineView(filledView("v_etf_filled_hfq", ViewETFHFQ, ClassETF, "hfq_factor"))
+)
+
+// tradingDaysCTE 返回 bounds (日线最早 / 最晚日期) 与 trading_days (其间全部交易日) 两个 CTE，
+// 两种方言只有日期序列与星期函数的写法不同。
+func tradingDaysCTE() (duckdb, clickhouse string) {
+	bounds := fmt.Sprintf(`bounds AS (
+			SELECT min(date) AS lo, max(date) AS hi FROM %s
+		)`, TableKlineDaily.TableName)
+
+	duckdb = fmt.Sprintf(`%s,
+		trading_days AS (
+			SELECT date FROM (
+				SELECT CAST(unnest(generate_series(CAST(lo AS TIMESTAMP), CAST(hi AS TIMESTAMP), INTERVAL 1 DAY)) AS DATE) AS date
+				FROM bounds
+			)
+			WHERE isodow(date) <= 5 AND date NOT IN (SELECT date FROM %s)
+		)`, bounds, TableHoliday.TableName)
+
+	clickhouse = fmt.Sprintf(`%s,
+		trading_days AS (
+			SELECT date FROM (
+				SELECT arrayJoin(arrayMap(i -> lo + i, range(toUInt32(hi - lo) + 1))) AS date
+				FROM bounds
+			)
+			WHERE toDayOfWeek(date) <= 5 AND date NOT IN (SELECT date FROM %s)
+		)`, bounds, TableHoliday.TableName)
+	return duckdb, clickhouse
+}
+
+func filledView(name string, base ViewDef, class string, factorCols ...string) ViewDef {
+	var factors strings.Builder
+	for _, c := range factorCols {
+		fmt.Fprintf(&factors, ",\n\t\t\tv.%s AS %s", c, c)
+	}
+	body := func(ctes string) string {
+		return fmt.Sprintf(`
+		WITH %s,
+		grid AS (
+			SELECT l.symbol AS symbol, t.date AS date
+			FROM trading_days t
+			CROSS JOIN %s l
+			CROSS JOIN bounds b
+			WHERE l.class = '%s'
+				AND t.date >= l.first_date
+				AND t.date <= CASE WHEN l.status = '%s' THEN l.last_date ELSE b.hi END
+		)
+		SELECT
+			g.symbol AS symbol,
+			g.date   AS date,
+			CASE WHEN v.date = g.date THEN v.open ELSE v.close END AS open,
+			CASE WHEN v.date = g.date THEN v.high ELSE v.close END AS high,
+			CASE WHEN v.date = g.date THEN v.low ELSE v.close END AS low,
+			v.close AS close,
+			CASE WHEN v.date = g.date THEN v.preclose ELSE v.close END AS preclose,
+			CASE WHEN v.date = g.date THEN v.volume ELSE 0 END AS volume,
+			CASE WHEN v.date = g.date THEN v.amount ELSE 0 END AS amount,
+			CASE WHEN v.date = g.date THEN v.turnover ELSE 0 END AS turnover,
+			v.floatmv AS floatmv,
+			v.totalmv AS totalmv,
+			CASE WHEN v.date = g.date THEN v.change_pct ELSE 0 END AS change_pct,
+			CASE WHEN v.date = g.date THEN v.amplitude ELSE 0 END AS amplitude%s,
+			CASE WHEN v.date = g.date THEN 0 ELSE 1 END AS is_suspended
+		FROM grid g
+		ASOF LEFT JOIN %s v ON g.symbol = v.symbol AND g.date >= v.date
+	`, ctes, TableSymbolLifecycle.TableName, class, StatusDelisted, factors.String(), base.Name)
+	}
+	duck, ch := tradingDaysCTE()
+	return ViewDef{Name: name, DuckDB: body(duck), ClickHouse: body(ch)}
+}
@@ -0,0 +1,270 @@
+// Package notify 在每次 cron 结束后把运行摘要推送到 webhook。
+// 支持通用 JSON POST 以及企业微信 / 钉钉 / 飞书 / Telegram 机器人的消息格式。
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+// 运行结论：ErrorModeSkip 的任务失败时整体仍会跑完，记为 partial。
+const (
+	StatusSuccess = "success"
+	StatusPartial = "partial"
+	StatusFailure = "failure"
+)
+
+// 支持的 webhook 类型。
+const (
+	KindJSON     = "json"
+	KindWeCom    = "wecom"
+	KindDingTalk = "dingtalk"
+	KindFeishu   = "feishu"
+	KindTelegram = "telegram"
+)
+
+// TaskSummary 是单个任务的结果摘要。
+type TaskSummary struct {
+	Name     string  `json:"name"`
+	State    string  `json:"state"`
+	Rows     int     `json:"rows"`
+	Duration float64 `json:"duration_seconds"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// TableDate 是某张 raw 表在本次运行后的最新日期，空表为 ""。
+type TableDate struct {
+	Table  string `json:"table"`
+	Latest string `json:"latest"`
+}
+
+// RunSummary 是一次 cron 运行的摘要，通用 JSON webhook 直接收到本结构。
+type RunSummary struct {
+	Status      string        `json:"status"`
+	Reason      string        `json:"reason"`
+	Error       string        `json:"error,omitempty"`
+	StartedAt   time.Time     `json:"started_at"`
+	FinishedAt  time.Time     `json:"finished_at"`
+	Tasks       []TaskSummary `json:"tasks"`
+	LatestDates []TableDate   `json:"latest_dates"`
+}
+
+// Target 是一个 webhook 目标。
+type Target struct {
+	Kind string
+	URL  string
+}
+
+// ParseTarget 解析 "<kind>=<url>"；省略 kind 时按通用 JSON 处理。
+// Telegram 的 chat_id 以 URL query 传入，如
+// telegram=https://api.telegram.org/bot<token>/sendMessage?chat_id=123。
+func ParseTarget(spec string) (Target, error) {
+	kind, rawURL := KindJSON, strings.TrimSpace(spec)
+	if i := strings.Index(rawURL, "="); i > 0 && !strings.Contains(rawURL[:i], "://") {
+		kind, rawURL = strings.ToLower(rawURL[:i]), rawURL[i+1:]
+	}
+	switch kind {
+	case KindJSON, KindWeCom, KindDingTalk, KindFeishu, KindTelegram:
+	default:
+		return Target{}, fmt.Errorf("unsupported webhook kind %q (json|wecom|dingtalk|feishu|telegram)", kind)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return Target{}, fmt.Errorf("invalid webhook url %q", rawURL)
+	}
+	if kind == KindTelegram && u.Query().Get("chat_id") == "" {
+		return Target{}, fmt.Errorf("telegram webhook requires chat_id query parameter")
+	}
+	return Target{Kind: kind, URL: rawURL}, nil
+}
+
+// Send 把摘要推送到全部目标；单个目标失败不影响其余目标，错误合并返回。
+func Send(ctx context.Context, targets []Target, s *RunSummary) error {
+	client := &http.Client{Timeout: 15 * time.Second}
+	var errs []error
+	for _, t := range targets {
+		if err := send(ctx, client, t, s); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", t.Kind, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func send(ctx context.Context, client *http.Client, t Target, s *RunSummary) error {
+	endpoint, body, err := payload(t, s)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// payload 按目标类型构造请求地址与 JSON body。
+func payload(t Target, s *RunSummary) (string, any, error) {
+	title := Title(s)
+	text := Render(s)
+	switch t.Kind {
+	case KindJSON:
+		return t.URL, s, nil
+	case KindWeCom:
+		return t.URL, map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": text},
+		}, nil
+	case KindDingTalk:
+		return t.URL, map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": title, "text": text},
+		}, nil
+	case KindFeishu:
+		return t.URL, map[string]any{
+			"msg_type": "text",
+			"content":  map[string]string{"text": text},
+		}, nil
+	case KindTelegram:
+		u, err := url.Parse(t.URL)
+		if err != nil {
+			return "", nil, err
+		}
+		q := u.Query()
+		chatID := q.Get("chat_id")
+		q.Del("chat_id")
+		u.RawQuery = q.Encode()
+		return u.String(), map[string]any{"chat_id": chatID, "text": text}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported webhook kind %q", t.Kind)
+	}
+}
+
+// Title 返回一行标题，如 "🚀 tdx2db cron 成功"。
+func Title(s *RunSummary) string {
+	switch s.Status {
+	case StatusSuccess:
+		return "🚀 tdx2db cron 成功"
+	case StatusPartial:
+		return "⚠️ tdx2db cron 部分失败"
+	default:
+		return "🛑 tdx2db cron 失败"
+	}
+}
+
+// Render 把摘要渲染成各机器人通用的纯文本 / 轻量 markdown。
+func Render(s *RunSummary) string {
+	var sb strings.Builder
+	sb.WriteString(Title(s))
+	sb.WriteString("\n")
+	if s.Reason != "" {
+		fmt.Fprintf(&sb, "计划: %s\n", s.Reason)
+	}
+	if s.Error != "" {
+		fmt.Fprintf(&sb, "错误: %s\n", s.Error)
+	}
+	fmt.Fprintf(&sb, "耗时: %s\n", s.FinishedAt.Sub(s.StartedAt).Round(time.Second))
+
+	if len(s.Tasks) > 0 {
+		sb.WriteString("\n任务:\n")
+		for _, t := range s.Tasks {
+			fmt.Fprintf(&sb, "- %s %s %s", taskMark(t.State, t.Error), t.Name, t.State)
+			if t.Rows > 0 {
+				fmt.Fprintf(&sb, " rows=%d", t.Rows)
+			}
+			if t.Duration > 0 {
+				fmt.Fprintf(&sb, " %.1fs", t.Duration)
+			}
+			if t.Error != "" {
+				fmt.Fprintf(&sb, ": %s", t.Error)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(s.LatestDates) > 0 {
+		sb.WriteString("\n最新日期:\n")
+		for _, d := range s.LatestDates {
+			latest := d.Latest
+			if latest == "" {
+				latest = "(空)"
+			}
+			fmt.Fprintf(&sb, "- %s %s\n", d.Table, latest)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func taskMark(state, errMsg string) string {
+	switch {
+	case errMsg != "" || state == "failed":
+		return "💢"
+	case state == "completed":
+		return "✅"
+	case state == "skipped":
+		return "⏭️"
+	default:
+		return "⏸️"
+	}
+}
+
+// BuildSummary 由执行器报告与各表最新日期组装运行摘要。
+func BuildSummary(reason string, reports []workflow.TaskReport, runErr error, dates []workflow.TableDate, started, finished time.Time) *RunSummary {
+	s := &RunSummary{
+		Status:     StatusSuccess,
+		Reason:     reason,
+		StartedAt:  started,
+		FinishedAt: finished,
+	}
+	switch {
+	case runErr != nil:
+		s.Status = StatusFailure
+		s.Error = runErr.Error()
+	case workflow.HasFailures(reports):
+		s.Status = StatusPartial
+	}
+
+	for _, r := range reports {
+		t := TaskSummary{
+			Name:     r.Name,
+			State:    string(r.State),
+			Rows:     r.Rows,
+			Duration: r.Duration.Seconds(),
+		}
+		if r.Error != nil {
+			t.Error = r.Error.Error()
+		}
+		s.Tasks = append(s.Tasks, t)
+	}
+	for _, d := range dates {
+		td := TableDate{Table: d.Table}
+		if !d.Latest.IsZero() {
+			td.Latest = d.Latest.Format("2006-01-02")
+		}
+		s.LatestDates = append(s.LatestDates, td)
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+func TestParseTarget(t *testing.T) {
+	cases := []struct {
+		spec    string
+		kind    string
+		wantErr bool
+	}{
+		{"https://example.com/hook", KindJSON, false},
+		{"WeCom=https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=a=b", KindWeCom, false},
+		{"telegram=https://api.telegram.org/botX/sendMessage?chat_id=1", KindTelegram, false},
+		{"telegram=https://api.telegram.org/botX/sendMessage", "", true},
+		{"slack=https://hooks.slack.com/x", "", true},
+		{"wecom=not-a-url", "", true},
+	}
+	for _, c := range cases {
+		got, err := ParseTarget(c.spec)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("ParseTarget(%q) err = %v, wantErr %v", c.spec, err, c.wantErr)
+		}
+		if err == nil && got.Kind != c.kind {
+			t.Fatalf("ParseTarget(%q) kind = %q, want %q", c.spec, got.Kind, c.kind)
+		}
+	}
+}
+
+func TestBuildSummaryPartial(t *testing.T) {
+	reports := []workflow.TaskReport{
+		{Name: "update_daily", TaskResult: workflow.TaskResult{State: workflow.StateCompleted, Rows: 10}},
+		{Name: "update_blocks", TaskResult: workflow.TaskResult{State: workflow.StateFailed, Error: errors.New("timeout")}},
+	}
+	dates := []workflow.TableDate{{Table: "raw_kline_daily", Latest: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)}}
+	s := BuildSummary("📅 需要更新", reports, nil, dates, time.Now(), time.Now())
+	if s.Status != StatusPartial {
+		t.Fatalf("status = %q, want partial", s.Status)
+	}
+	if s.Tasks[1].Error != "timeout" || s.LatestDates[0].Latest != "2026-10-16" {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+	text := Render(s)
+	if !strings.Contains(text, "💢 update_blocks") || !strings.Contains(text, "raw_kline_daily 2026-10-16") {
+		t.Fatalf("unexpected render:\n%s", text)
+	}
+}
+
+func TestSendTelegramMovesChatID(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.String()
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &gotBody)
+	}))
+	defer srv.Close()
+
+	target, err := ParseTarget("telegram=" + srv.URL + "/botX/sendMessage?chat_id=42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := BuildSummary("", nil, nil, nil, time.Now(), time.Now())
+	if err := Send(context.Background(), []Target{target}, s); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/botX/sendMessage" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if gotBody["chat_id"] != "42" || !strings.HasPrefix(gotBody["text"].(string), "🚀") {
+		t.Fatalf("unexpected body: %v", gotBody)
+	}
+}
+
+func TestSendReportsHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s := BuildSummary("", nil, errors.New("boom"), nil, time.Now(), time.Now())
+	err := Send(context.Background(), []Target{{Kind: KindWeCom, URL: srv.URL}}, s)
+	if err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Fatalf("err = %v", err)
+	}
+}
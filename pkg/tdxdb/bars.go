@@ -0,0 +1,192 @@
+package tdxdb
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jing2uo/tdx2db/model"
+)
+
+// dailySource 返回 class 在 fq 下的数据源与 SELECT 列。
+// 有视图的 class 走对应日线视图 (指数、板块只有 bfq)；没有视图的只支持 BFQ，直接读 raw_kline_daily。
+func dailySource(class string, fq FQ) (string, []string, error) {
+	if view, ok := model.DailyView(class, string(fq)); ok {
+		factor := "CAST(1 AS DOUBLE) AS factor"
+		switch fq {
+		case QFQ:
+			factor = "qfq_factor AS factor"
+		case HFQ:
+			factor = "hfq_factor AS factor"
+		}
+		return view, []string{
+			"symbol", "date", "open", "high", "low", "close",
+			"COALESCE(preclose, 0) AS preclose", "volume", "amount",
+			"COALESCE(turnover, 0) AS turnover",
+			"COALESCE(floatmv, 0) AS floatmv",
+			"COALESCE(totalmv, 0) AS totalmv",
+			"COALESCE(change_pct, 0) AS change_pct",
+			"COALESCE(amplitude, 0) AS amplitude",
+			factor,
+		}, nil
+	}
+	if fq != BFQ {
+		return "", nil, fmt.Errorf("tdxdb: fq=%s is not available for class %s", fq, class)
+	}
+	zero := "CAST(0 AS DOUBLE)"
+	return model.TableKlineDaily.TableName, []string{
+		"symbol", "date", "open", "high", "low", "close",
+		zero + " AS preclose", "volume", "amount",
+		zero + " AS turnover", zero + " AS floatmv", zero + " AS totalmv",
+		zero + " AS change_pct", zero + " AS amplitude",
+		"CAST(1 AS DOUBLE) AS factor",
+	}, nil
+}
+
+// between 把 [start, end] 转成条件，零值表示不限。
+func between(col string, start, end time.Time) []model.Cond {
+	var conds []model.Cond
+	if !start.IsZero() {
+		conds = append(conds, model.Cond{Column: col, Op: ">=", Value: start})
+	}
+	if !end.IsZero() {
+		conds = append(conds, model.Cond{Column: col, Op: "<=", Value: end})
+	}
+	return conds
+}
+
+// DailyBars 返回单个代码在 [start, end] 内按 fq 复权的日线，按日期升序。
+// start / end 为零值表示不限。
+func (c *Client) DailyBars(symbol string, fq FQ, start, end time.Time) ([]model.DailyBar, error) {
+	symbol = normalizeSymbol(symbol)
+	class, err := c.Class(symbol)
+	if err != nil {
+		return nil, err
+	}
+	table, cols, err := dailySource(class, fq)
+	if err != nil {
+		return nil, err
+	}
+
+	var bars []model.DailyBar
+	err = c.db.QueryInto(model.RowQuery{
+		Table:   table,
+		Columns: cols,
+		Where:   append([]model.Cond{{Column: "symbol", Op: "=", Value: symbol}}, between("date", start, end)...),
+		OrderBy: []string{"date"},
+	}, &bars)
+	return bars, err
+}
+
+// CrossSection 返回某个 class 在 date 当天的全部日线，按代码升序。
+func (c *Client) CrossSection(date time.Time, class string, fq FQ) ([]model.DailyBar, error) {
+	table, cols, err := dailySource(class, fq)
+	if err != nil {
+		return nil, err
+	}
+	where := []model.Cond{{Column: "date", Op: "=", Value: date}}
+
+	// raw_kline_daily 不带 class，先取该类代码再过滤。
+	if table == model.TableKlineDaily.TableName {
+		symbols, err := c.db.GetSymbolsByClass(class)
+		if err != nil {
+			return nil, err
+		}
+		if len(symbols) == 0 {
+			return nil, nil
+		}
+		where = append(where, model.Cond{Column: "symbol", Op: "IN", Value: symbols})
+	}
+
+	var bars []model.DailyBar
+	err = c.db.QueryInto(model.RowQuery{
+		Table:   table,
+		Columns: cols,
+		Where:   where,
+		OrderBy: []string{"symbol"},
+	}, &bars)
+	return bars, err
+}
+
+// MinuteBars 返回单个代码在 [start, end] 内的 1 分钟线，按时间升序。
+// start / end 按日期取整，end 当天整天都包含。
+// 复权用当日日线的 hfq 因子，QFQ 再除以最新因子，ROUND 精度与日线视图一致。
+func (c *Client) MinuteBars(symbol string, fq FQ, start, end time.Time) ([]model.KlineMin, error) {
+	symbol = normalizeSymbol(symbol)
+	where := []model.Cond{{Column: "symbol", Op: "=", Value: symbol}}
+	if !start.IsZero() {
+		where = append(where, model.Cond{Column: "datetime", Op: ">=", Value: start.Truncate(24 * time.Hour)})
+	}
+	if !end.IsZero() {
+		where = append(where, model.Cond{Column: "datetime", Op: "<", Value: end.Truncate(24*time.Hour).AddDate(0, 0, 1)})
+	}
+
+	var bars []model.KlineMin
+	err := c.db.QueryInto(model.RowQuery{
+		Table:   model.TableKline1Min.TableName,
+		Where:   where,
+		OrderBy: []string{"datetime"},
+	}, &bars)
+	if err != nil || fq == BFQ || len(bars) == 0 {
+		return bars, err
+	}
+
+	class, err := c.Class(symbol)
+	if err != nil {
+		return nil, err
+	}
+	factors, err := c.Factors(symbol, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return adjustMinutes(bars, factors, fq, model.PricePrecision(class)), nil
+}
+
+// adjustMinutes 按日匹配 hfq 因子复权分钟线；缺因子的日期按 1 处理，与视图的 COALESCE 一致。
+func adjustMinutes(bars []model.KlineMin, factors []model.Factor, fq FQ, precision int) []model.KlineMin {
+	byDate := make(map[string]float64, len(factors))
+	latest := 1.0
+	for _, f := range factors {
+		byDate[f.Date.Format("2006-01-02")] = f.HfqFactor
+		latest = f.HfqFactor // Factors 按日期升序
+	}
+
+	scale := math.Pow10(precision)
+	round := func(v float64) float64 { return math.Round(v*scale) / scale }
+
+	out := make([]model.KlineMin, len(bars))
+	for i, b := range bars {
+		f, ok := byDate[b.Datetime.Format("2006-01-02")]
+		if !ok {
+			f = 1
+		}
+		if fq == QFQ && latest != 0 {
+			f /= latest
+		}
+		b.Open, b.High, b.Low, b.Close = round(b.Open*f), round(b.High*f), round(b.Low*f), round(b.Close*f)
+		out[i] = b
+	}
+	return out
+}
+
+// Factors 返回单个代码在 [start, end] 内的后复权因子，按日期升序。
+func (c *Client) Factors(symbol string, start, end time.Time) ([]model.Factor, error) {
+	var factors []model.Factor
+	err := c.db.QueryInto(model.RowQuery{
+		Table:   model.TableAdjustFactor.TableName,
+		Where:   append([]model.Cond{{Column: "symbol", Op: "=", Value: normalizeSymbol(symbol)}}, between("date", start, end)...),
+		OrderBy: []string{"date"},
+	}, &factors)
+	return factors, err
+}
+
+// Basics 返回 date 当天全部代码的每日指标 (换手率、市值等)，按代码升序。
+func (c *Client) Basics(date time.Time) ([]model.BasicDaily, error) {
+	var basics []model.BasicDaily
+	err := c.db.QueryInto(model.RowQuery{
+		Table:   model.TableBasicDaily.TableName,
+		Where:   []model.Cond{{Column: "date", Op: "=", Value: date}},
+		OrderBy: []string{"symbol"},
+	}, &basics)
+	return basics, err
+}
@@ -0,0 +1,21 @@
+package tdxdb
+
+import (
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+// Calendar 是基于 raw_holidays + 周末推算的交易日历，Next / Prev / AddTradingDays /
+// NextTradingDays / TradingDays 等方法来自 workflow.TradingCalendar。
+// 超出节假日数据覆盖范围的日期只按周末判断。
+type Calendar struct {
+	*workflow.TradingCalendar
+}
+
+// Calendar 读取节假日表构造交易日历；节假日表每次 gbbq 更新时刷新，调用方可按需缓存。
+func (c *Client) Calendar() (*Calendar, error) {
+	holidays, err := c.db.GetHolidays()
+	if err != nil {
+		return nil, err
+	}
+	return &Calendar{workflow.NewTradingCalendar(holidays)}, nil
+}
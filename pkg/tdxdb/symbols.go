@@ -0,0 +1,117 @@
+package tdxdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jing2uo/tdx2db/model"
+)
+
+// Symbol 返回单个代码的名称与分类。
+func (c *Client) Symbol(symbol string) (model.SymbolName, error) {
+	var rows []model.SymbolName
+	err := c.db.QueryInto(model.RowQuery{
+		Table: model.TableSymbolName.TableName,
+		Where: []model.Cond{{Column: "symbol", Op: "=", Value: normalizeSymbol(symbol)}},
+		Limit: 1,
+	}, &rows)
+	if err != nil {
+		return model.SymbolName{}, err
+	}
+	if len(rows) == 0 {
+		return model.SymbolName{}, fmt.Errorf("%w: symbol %s", ErrNotFound, symbol)
+	}
+	return rows[0], nil
+}
+
+// SearchSymbols 按代码或名称包含 keyword (不区分大小写) 搜索，classes 为空表示不限分类。
+// raw_symbol_name 只有一万多行，整表取回在内存里匹配，两种后端行为一致。
+func (c *Client) SearchSymbols(keyword string, classes ...string) ([]model.SymbolName, error) {
+	q := model.RowQuery{
+		Table:   model.TableSymbolName.TableName,
+		OrderBy: []string{"symbol"},
+	}
+	if len(classes) > 0 {
+		q.Where = []model.Cond{{Column: "class", Op: "IN", Value: classes}}
+	}
+	var rows []model.SymbolName
+	if err := c.db.QueryInto(q, &rows); err != nil {
+		return nil, err
+	}
+
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	if keyword == "" {
+		return rows, nil
+	}
+	matched := rows[:0]
+	for _, r := range rows {
+		if strings.Contains(r.Symbol, keyword) || strings.Contains(strings.ToLower(r.Name), keyword) {
+			matched = append(matched, r)
+		}
+	}
+	return matched, nil
+}
+
+// blockInfoColumns 显式列出板块列：顶层板块的 parent_code 以 NULL 入库，扫描前转成空串。
+var blockInfoColumns = []string{
+	"block_type", "block_name", "block_symbol", "block_code",
+	"COALESCE(parent_code, '') AS parent_code", "block_level",
+}
+
+// Blocks 返回通达信板块列表，blockType 为空表示全部类型。
+func (c *Client) Blocks(blockType string) ([]model.BlockInfo, error) {
+	q := model.RowQuery{
+		Table:   model.TableBlockInfo.TableName,
+		Columns: blockInfoColumns,
+		OrderBy: []string{"block_code"},
+	}
+	if blockType != "" {
+		q.Where = []model.Cond{{Column: "block_type", Op: "=", Value: blockType}}
+	}
+	var blocks []model.BlockInfo
+	err := c.db.QueryInto(q, &blocks)
+	return blocks, err
+}
+
+// BlockMembers 返回板块的成分股代码，按代码升序。
+func (c *Client) BlockMembers(blockCode string) ([]string, error) {
+	var members []model.BlockMember
+	err := c.db.QueryInto(model.RowQuery{
+		Table:   model.TableBlockMember.TableName,
+		Where:   []model.Cond{{Column: "block_code", Op: "=", Value: blockCode}},
+		OrderBy: []string{"stock_symbol"},
+	}, &members)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]string, len(members))
+	for i, m := range members {
+		symbols[i] = m.StockSymbol
+	}
+	return symbols, nil
+}
+
+// SymbolBlocks 返回包含该股票的全部板块。
+func (c *Client) SymbolBlocks(symbol string) ([]model.BlockInfo, error) {
+	var members []model.BlockMember
+	err := c.db.QueryInto(model.RowQuery{
+		Table: model.TableBlockMember.TableName,
+		Where: []model.Cond{{Column: "stock_symbol", Op: "=", Value: normalizeSymbol(symbol)}},
+	}, &members)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	codes := make([]string, len(members))
+	for i, m := range members {
+		codes[i] = m.BlockCode
+	}
+	var blocks []model.BlockInfo
+	err = c.db.QueryInto(model.RowQuery{
+		Table:   model.TableBlockInfo.TableName,
+		Columns: blockInfoColumns,
+		Where:   []model.Cond{{Column: "block_code", Op: "IN", Value: codes}},
+		OrderBy: []string{"block_code"},
+	}, &blocks)
+	return blocks, err
+}
@@ -0,0 +1,103 @@
+// Package tdxdb 是给其他 Go 服务使用的只读查询 SDK：
+// 按复权方式取日线 / 分钟线、按日期取截面、搜索代码、查板块、交易日历。
+// 底层走 database.DataRepository，DuckDB 与 ClickHouse 均可，返回 model 包里的结构体。
+//
+//	c, err := tdxdb.Open("duckdb://tdx.db?access_mode=read_only")
+//	bars, err := c.DailyBars("sz000001", tdxdb.QFQ, start, end)
+package tdxdb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+)
+
+// ErrNotFound 表示代码 / 板块不存在。
+var ErrNotFound = errors.New("tdxdb: not found")
+
+// FQ 是复权方式。
+type FQ string
+
+const (
+	BFQ FQ = "bfq" // 不复权
+	QFQ FQ = "qfq" // 前复权，以最新交易日为基准
+	HFQ FQ = "hfq" // 后复权，以上市首日为基准
+)
+
+// Client 是 SDK 入口，并发安全程度与底层 driver 一致。
+type Client struct {
+	db     database.DataRepository
+	closer bool
+}
+
+// Open 按 dburi 连接数据库并检查 schema 主版本，dburi 格式同命令行 --dburi。
+func Open(dbURI string) (*Client, error) {
+	db, err := database.NewDB(dbURI)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := checkSchemaMajor(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Client{db: db, closer: true}, nil
+}
+
+// New 包装一个已连接的 DataRepository，Close 时不会关闭它。
+func New(db database.DataRepository) *Client {
+	return &Client{db: db}
+}
+
+// Close 关闭由 Open 建立的连接。
+func (c *Client) Close() error {
+	if !c.closer {
+		return nil
+	}
+	return c.db.Close()
+}
+
+func checkSchemaMajor(db database.DataRepository) error {
+	ver, err := db.ReadSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if ver == "" {
+		return fmt.Errorf("tdxdb: schema version missing, run `tdx2db init` first")
+	}
+	major, err := strconv.Atoi(strings.SplitN(ver, ".", 2)[0])
+	if err != nil {
+		return fmt.Errorf("tdxdb: invalid schema version %q", ver)
+	}
+	if major != model.SchemaMajor {
+		return fmt.Errorf("tdxdb: schema major %d does not match SDK major %d", major, model.SchemaMajor)
+	}
+	return nil
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.ToLower(strings.TrimSpace(symbol))
+}
+
+// Class 返回代码所属分类 (model.ClassStock 等)，不存在时返回 ErrNotFound。
+func (c *Client) Class(symbol string) (string, error) {
+	var rows []model.SymbolClass
+	err := c.db.QueryInto(model.RowQuery{
+		Table: model.TableSymbolClass.TableName,
+		Where: []model.Cond{{Column: "symbol", Op: "=", Value: normalizeSymbol(symbol)}},
+		Limit: 1,
+	}, &rows)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("%w: symbol %s", ErrNotFound, symbol)
+	}
+	return rows[0].Class, nil
+}
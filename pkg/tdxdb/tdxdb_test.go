@@ -0,0 +1,284 @@
+package tdxdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+// openTestDB 建一个临时 DuckDB，导入两天日线 + 一次除权的因子。
+func openTestDB(t *testing.T) *Client {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := database.NewDB("duckdb://" + filepath.Join(dir, "tdx.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(db.ImportKlineDaily(write("daily.csv", "symbol,open,high,low,close,amount,volume,date\n"+
+		"sz000001,10,11,9,10,100,10,2024-01-02\n"+
+		"sz000001,5,6,4,5,100,10,2024-01-03\n"+
+		"sh000001,3000,3010,2990,3005,1,1,2024-01-03\n"+
+		"sh900901,0.512,0.52,0.5,0.515,1,1,2024-01-03\n"+
+		"sh880471,1000,1010,990,1005,1,1,2024-01-03\n")))
+	must(db.ImportKline1Min(write("min.csv", "symbol,open,high,low,close,amount,volume,datetime\n"+
+		"sz000001,10,10,10,10,1,1,2024-01-02 09:31\n"+
+		"sz000001,5,5,5,5,1,1,2024-01-03 09:31\n")))
+	must(db.ImportAdjustFactors(write("factor.csv", "symbol,date,hfq_factor\n"+
+		"sz000001,2024-01-02,1\nsz000001,2024-01-03,2\n")))
+	must(db.ImportSymbolNames(write("names.csv", "symbol,name,class\n"+
+		"sz000001,平安银行,stock\nsh000001,上证指数,index\n")))
+	must(db.ImportBlockInfo(write("blocks.csv", "block_type,block_name,block_symbol,block_code,parent_code,block_level\n"+
+		"hy,银行,sh880471,X01,,1\n")))
+	must(db.ImportBlockMembers(write("members.csv", "stock_symbol,block_code\nsz000001,X01\n")))
+	must(db.RebuildSymbolClass())
+	return New(db)
+}
+
+func TestDailyBarsAndCrossSection(t *testing.T) {
+	c := openTestDB(t)
+
+	bars, err := c.DailyBars("SZ000001", QFQ, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 2 || bars[0].Close != 5 || bars[0].Factor != 0.5 || bars[1].Close != 5 {
+		t.Fatalf("unexpected qfq bars: %+v", bars)
+	}
+
+	bars, err = c.DailyBars("sz000001", HFQ, date("2024-01-03"), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 1 || bars[0].Close != 10 {
+		t.Fatalf("unexpected hfq bars: %+v", bars)
+	}
+
+	if _, err := c.DailyBars("sh000001", QFQ, time.Time{}, time.Time{}); err == nil {
+		t.Fatal("expected error for index qfq")
+	}
+	if _, err := c.DailyBars("sh999999", BFQ, time.Time{}, time.Time{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+
+	idx, err := c.CrossSection(date("2024-01-03"), model.ClassIndex, BFQ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx) != 1 || idx[0].Symbol != "sh000001" || idx[0].Factor != 1 {
+		t.Fatalf("unexpected index cross section: %+v", idx)
+	}
+}
+
+func TestBStockViews(t *testing.T) {
+	c := openTestDB(t)
+
+	bars, err := c.DailyBars("sh900901", QFQ, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 1 || bars[0].Close != 0.515 {
+		t.Fatalf("unexpected bstock bars: %+v", bars)
+	}
+
+	rows, err := c.db.QueryRows(model.RowQuery{Table: "v_bstock_bfq", Columns: []string{"symbol", "currency"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows.Rows) != 1 || rows.Rows[0][1] != "USD" {
+		t.Fatalf("unexpected bstock currency: %+v", rows.Rows)
+	}
+}
+
+func TestBlockDailyView(t *testing.T) {
+	c := openTestDB(t)
+
+	rows, err := c.db.QueryRows(model.RowQuery{Table: "v_block_daily", Columns: []string{"symbol", "close", "block_name", "block_type"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows.Rows) != 1 || rows.Rows[0][2] != "银行" || rows.Rows[0][3] != "hy" {
+		t.Fatalf("unexpected block daily rows: %+v", rows.Rows)
+	}
+
+	bars, err := c.CrossSection(date("2024-01-03"), model.ClassBlock, BFQ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 1 || bars[0].Close != 1005 {
+		t.Fatalf("unexpected block cross section: %+v", bars)
+	}
+}
+
+func TestMinuteBarsQFQ(t *testing.T) {
+	c := openTestDB(t)
+	bars, err := c.MinuteBars("sz000001", QFQ, date("2024-01-02"), date("2024-01-03"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 2 || bars[0].Close != 5 || bars[1].Close != 5 {
+		t.Fatalf("unexpected minute bars: %+v", bars)
+	}
+}
+
+func TestSymbolsAndBlocks(t *testing.T) {
+	c := openTestDB(t)
+
+	found, err := c.SearchSymbols("银行")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Symbol != "sz000001" {
+		t.Fatalf("search = %+v", found)
+	}
+	if found, _ := c.SearchSymbols("000001", model.ClassIndex); len(found) != 1 || found[0].Symbol != "sh000001" {
+		t.Fatalf("search by class = %+v", found)
+	}
+
+	blocks, err := c.SymbolBlocks("sz000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].BlockName != "银行" {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	members, err := c.BlockMembers("X01")
+	if err != nil || len(members) != 1 {
+		t.Fatalf("members = %v, err %v", members, err)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	c := openTestDB(t)
+	cal, err := c.Calendar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fri := date("2024-01-05")
+	if got := cal.Next(fri); !got.Equal(date("2024-01-08")) {
+		t.Fatalf("Next = %s", got)
+	}
+	if got := cal.AddTradingDays(fri, -2); !got.Equal(date("2024-01-03")) {
+		t.Fatalf("AddTradingDays = %s", got)
+	}
+	if days := cal.TradingDays(date("2024-01-05"), date("2024-01-08")); len(days) != 2 {
+		t.Fatalf("TradingDays = %v", days)
+	}
+	if days := cal.NextTradingDays(fri, 2); len(days) != 2 || !days[1].Equal(date("2024-01-09")) {
+		t.Fatalf("NextTradingDays = %v", days)
+	}
+
+	// 连续 40 天假期：不再受 30 天查找上限影响。
+	var holidays []time.Time
+	for d := date("2024-03-01"); d.Before(date("2024-04-10")); d = d.AddDate(0, 0, 1) {
+		holidays = append(holidays, d)
+	}
+	long := workflow.NewTradingCalendar(holidays)
+	if got := long.LastTradingDayOnOrBefore(date("2024-04-09")); !got.Equal(date("2024-02-29")) {
+		t.Fatalf("LastTradingDayOnOrBefore = %s", got)
+	}
+	if got := long.Next(date("2024-02-29")); !got.Equal(date("2024-04-10")) {
+		t.Fatalf("Next across long holiday = %s", got)
+	}
+
+	rows := cal.Rows(date("2024-01-05"), date("2024-01-08"))
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 calendar rows, got %+v", rows)
+	}
+	sat, mon := rows[1], rows[3]
+	if sat.IsTradingDay != 0 || sat.TradeSeq != 1 || !sat.PrevTradingDay.Equal(fri) || !sat.NextTradingDay.Equal(mon.Date) {
+		t.Fatalf("unexpected saturday row: %+v", sat)
+	}
+	if mon.IsTradingDay != 1 || mon.TradeSeq != 2 || !mon.PrevTradingDay.Equal(fri) || !mon.NextTradingDay.Equal(date("2024-01-09")) {
+		t.Fatalf("unexpected monday row: %+v", mon)
+	}
+	if first := cal.Rows(workflow.CalendarStart, workflow.CalendarStart)[0]; first.PrevTradingDay != nil || first.TradeSeq != 1 {
+		t.Fatalf("unexpected first calendar row: %+v", first)
+	}
+}
+
+func TestFilledDailyView(t *testing.T) {
+	c := openTestDB(t)
+	dir := t.TempDir()
+
+	// sz000002 只有 01-02 一根 K 线，01-03 停牌。
+	daily := filepath.Join(dir, "daily.csv")
+	if err := os.WriteFile(daily, []byte("symbol,open,high,low,close,amount,volume,date\n"+
+		"sz000002,7,7.5,6.5,7,100,10,2024-01-02\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.db.ImportKlineDaily(daily); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.db.RebuildSymbolClass(); err != nil {
+		t.Fatal(err)
+	}
+	d1, d2 := date("2024-01-02"), date("2024-01-03")
+	lifecycle := model.UpdateSymbolLifecycle(nil, []model.SymbolLifecycle{
+		{Symbol: "sz000001", FirstDate: &d1, LastDate: &d2},
+		{Symbol: "sz000002", FirstDate: &d1, LastDate: &d1},
+	}, nil, d2)
+	cw, err := utils.NewCSVWriter[model.SymbolLifecycle](filepath.Join(dir, "lifecycle.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Write(lifecycle); err != nil {
+		t.Fatal(err)
+	}
+	cw.Close()
+	if err := c.db.ImportCSV(model.TableSymbolLifecycle, filepath.Join(dir, "lifecycle.csv")); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := c.db.QueryRows(model.RowQuery{
+		Table:   "v_stock_filled_bfq",
+		Columns: []string{"symbol", "open", "close", "volume", "is_suspended"},
+		OrderBy: []string{"symbol", "date"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows.Rows) != 4 {
+		t.Fatalf("expected 4 rows, got %+v", rows.Rows)
+	}
+	last := rows.Rows[3]
+	if last[0] != "sz000002" || last[1] != 7.0 || last[2] != 7.0 || last[3] != int64(0) || fmt.Sprint(last[4]) != "1" {
+		t.Fatalf("unexpected suspended row: %+v", last)
+	}
+	if fmt.Sprint(rows.Rows[2][4]) != "0" {
+		t.Fatalf("unexpected trading row: %+v", rows.Rows[2])
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jing2uo/tdx2db/model"
+)
+
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+// writeRows 输出一页结果。set 比 limit 多一行时表示还有下一页：
+// JSON 里给出 next_offset，CSV 放在 X-Next-Offset 响应头。
+func writeRows(w http.ResponseWriter, p page, set *model.RowSet) {
+	rows := set.Rows
+	next := -1
+	if len(rows) > p.limit {
+		rows = rows[:p.limit]
+		next = p.offset + p.limit
+	}
+	if next >= 0 {
+		w.Header().Set("X-Next-Offset", strconv.Itoa(next))
+	}
+
+	if p.format == formatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		cw := csv.NewWriter(w)
+		_ = cw.Write(set.Columns)
+		record := make([]string, len(set.Columns))
+		for _, row := range rows {
+			for i, v := range row {
+				record[i] = csvValue(normalize(v))
+			}
+			_ = cw.Write(record)
+		}
+		cw.Flush()
+		return
+	}
+
+	// 手写外层对象，保证每行的 key 按列顺序输出。
+	var buf bytes.Buffer
+	buf.WriteString(`{"columns":`)
+	cols, _ := json.Marshal(set.Columns)
+	buf.Write(cols)
+	buf.WriteString(`,"data":[`)
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j, v := range row {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(set.Columns[j])
+			val, err := json.Marshal(normalize(v))
+			if err != nil {
+				val = []byte("null")
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(val)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteString(`],"limit":` + strconv.Itoa(p.limit))
+	buf.WriteString(`,"offset":` + strconv.Itoa(p.offset))
+	if next >= 0 {
+		buf.WriteString(`,"next_offset":` + strconv.Itoa(next))
+	}
+	buf.WriteString("}\n")
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// normalize 把驱动返回的值转成便于序列化的形式：
+// 零点时刻按日期输出，NaN / Inf 输出为 null。
+func normalize(v any) any {
+	switch x := v.(type) {
+	case time.Time:
+		if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 && x.Nanosecond() == 0 {
+			return x.Format("2006-01-02")
+		}
+		return x.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if x == nil {
+			return nil
+		}
+		return normalize(*x)
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil
+		}
+	case float32:
+		return normalize(float64(x))
+	}
+	return v
+}
+
+func csvValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(x)
+		if err != nil {
+			return ""
+		}
+		return strings.Trim(string(b), `"`)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	zw *gzip.Writer
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) { return g.zw.Write(b) }
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	g.Header().Del("Content-Length")
+	g.ResponseWriter.WriteHeader(status)
+}
+
+// gzipHandler 在客户端声明 Accept-Encoding: gzip 时压缩响应。
+func gzipHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, zw: zw}, r)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		slog.Debug("🌐 "+r.Method+" "+r.URL.RequestURI(),
+			"status", rec.status, "duration", time.Since(start))
+	})
+}
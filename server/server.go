@@ -0,0 +1,378 @@
+// Package server 提供只读 HTTP API，让下游直接按 HTTP 取数而不必连数据库。
+// 所有查询都经 database.DataRepository.QueryRows，DuckDB / ClickHouse 共用。
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/metrics"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/workflow"
+)
+
+const (
+	defaultLimit = 1000
+	maxLimit     = 10000
+)
+
+// Server 持有数据库连接并注册全部路由。
+type Server struct {
+	db  database.DataRepository
+	mux *http.ServeMux
+}
+
+func New(db database.DataRepository) *Server {
+	s := &Server{db: db, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /api/v1/daily/{symbol}", s.handleDaily)
+	s.mux.HandleFunc("GET /api/v1/minute/{symbol}", s.handleMinute)
+	s.mux.HandleFunc("GET /api/v1/basic", s.handleBasic)
+	s.mux.HandleFunc("GET /api/v1/factor", s.handleFactor)
+	s.mux.HandleFunc("GET /api/v1/symbols", s.handleSymbols)
+	s.mux.HandleFunc("GET /api/v1/blocks", s.handleBlocks)
+	s.mux.HandleFunc("GET /api/v1/blocks/{code}/members", s.handleBlockMembers)
+	s.mux.HandleFunc("GET /api/v1/calendar", s.handleCalendar)
+	s.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return s
+}
+
+// Handler 返回带 gzip 与访问日志的根 handler。
+func (s *Server) Handler() http.Handler {
+	return logRequests(gzipHandler(s.mux))
+}
+
+// httpError 携带 HTTP 状态码，handler 统一由 writeError 输出。
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func badRequest(format string, a ...any) error {
+	return &httpError{status: http.StatusBadRequest, msg: fmt.Sprintf(format, a...)}
+}
+
+func notFound(format string, a ...any) error {
+	return &httpError{status: http.StatusNotFound, msg: fmt.Sprintf(format, a...)}
+}
+
+// page 是分页与输出格式参数。
+type page struct {
+	limit  int
+	offset int
+	format string
+}
+
+func parsePage(r *http.Request) (page, error) {
+	q := r.URL.Query()
+	p := page{limit: defaultLimit, format: formatJSON}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return p, badRequest("invalid limit %q", v)
+		}
+		p.limit = min(n, maxLimit)
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, badRequest("invalid offset %q", v)
+		}
+		p.offset = n
+	}
+
+	switch f := strings.ToLower(q.Get("format")); f {
+	case "":
+		if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+			p.format = formatCSV
+		}
+	case formatJSON, formatCSV:
+		p.format = f
+	default:
+		return p, badRequest("invalid format %q (json|csv)", f)
+	}
+	return p, nil
+}
+
+// parseDate 接受 2006-01-02 与 20060102 两种写法。
+func parseDate(name, v string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "20060102"} {
+		if d, err := time.Parse(layout, v); err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, badRequest("invalid %s %q (YYYY-MM-DD)", name, v)
+}
+
+// dateRange 把 start / end 参数转成对 col 的条件；end 为闭区间。
+// datetime 列的 end 取次日零点、用 < 比较，保证包含 end 当天的分钟线。
+func dateRange(r *http.Request, col string, datetime bool) ([]model.Cond, error) {
+	var conds []model.Cond
+	q := r.URL.Query()
+	if v := q.Get("start"); v != "" {
+		d, err := parseDate("start", v)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, model.Cond{Column: col, Op: ">=", Value: d})
+	}
+	if v := q.Get("end"); v != "" {
+		d, err := parseDate("end", v)
+		if err != nil {
+			return nil, err
+		}
+		if datetime {
+			conds = append(conds, model.Cond{Column: col, Op: "<", Value: d.AddDate(0, 0, 1)})
+		} else {
+			conds = append(conds, model.Cond{Column: col, Op: "<=", Value: d})
+		}
+	}
+	return conds, nil
+}
+
+// serveQuery 执行分页查询并按格式输出。多取一行用来判断是否还有下一页。
+func (s *Server) serveQuery(w http.ResponseWriter, r *http.Request, q model.RowQuery) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	q.Limit, q.Offset = p.limit+1, p.offset
+
+	set, err := s.db.QueryRows(q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeRows(w, p, set)
+}
+
+func (s *Server) handleDaily(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToLower(r.PathValue("symbol"))
+	fq := strings.ToLower(r.URL.Query().Get("fq"))
+	if fq == "" {
+		fq = "bfq"
+	}
+	if fq != "bfq" && fq != "qfq" && fq != "hfq" {
+		writeError(w, r, badRequest("invalid fq %q (bfq|qfq|hfq)", fq))
+		return
+	}
+
+	class, err := s.symbolClass(symbol)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	table, ok := model.DailyView(class, fq)
+	if !ok {
+		if fq != "bfq" {
+			writeError(w, r, badRequest("fq=%s is not available for class %s", fq, class))
+			return
+		}
+		table = model.TableKlineDaily.TableName
+	}
+
+	conds, err := dateRange(r, "date", false)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.serveQuery(w, r, model.RowQuery{
+		Table:   table,
+		Where:   append([]model.Cond{{Column: "symbol", Op: "=", Value: symbol}}, conds...),
+		OrderBy: []string{"date"},
+	})
+}
+
+func (s *Server) symbolClass(symbol string) (string, error) {
+	set, err := s.db.QueryRows(model.RowQuery{
+		Table:   model.TableSymbolClass.TableName,
+		Columns: []string{"class"},
+		Where:   []model.Cond{{Column: "symbol", Op: "=", Value: symbol}},
+		Limit:   1,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(set.Rows) == 0 {
+		return "", notFound("symbol %s not found", symbol)
+	}
+	class, _ := set.Rows[0][0].(string)
+	return class, nil
+}
+
+func (s *Server) handleMinute(w http.ResponseWriter, r *http.Request) {
+	conds, err := dateRange(r, "datetime", true)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	symbol := strings.ToLower(r.PathValue("symbol"))
+	s.serveQuery(w, r, model.RowQuery{
+		Table:   model.TableKline1Min.TableName,
+		Where:   append([]model.Cond{{Column: "symbol", Op: "=", Value: symbol}}, conds...),
+		OrderBy: []string{"datetime"},
+	})
+}
+
+// bySymbolAndDate 处理 basic / factor 这类 (symbol, date) 表：
+// symbol 可选，date 取截面，start / end 取区间。
+func (s *Server) bySymbolAndDate(w http.ResponseWriter, r *http.Request, table string) {
+	conds, err := dateRange(r, "date", false)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	if v := q.Get("symbol"); v != "" {
+		conds = append(conds, model.Cond{Column: "symbol", Op: "=", Value: strings.ToLower(v)})
+	}
+	if v := q.Get("date"); v != "" {
+		d, err := parseDate("date", v)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		conds = append(conds, model.Cond{Column: "date", Op: "=", Value: d})
+	}
+	s.serveQuery(w, r, model.RowQuery{
+		Table:   table,
+		Where:   conds,
+		OrderBy: []string{"symbol", "date"},
+	})
+}
+
+func (s *Server) handleBasic(w http.ResponseWriter, r *http.Request) {
+	s.bySymbolAndDate(w, r, model.TableBasicDaily.TableName)
+}
+
+func (s *Server) handleFactor(w http.ResponseWriter, r *http.Request) {
+	s.bySymbolAndDate(w, r, model.TableAdjustFactor.TableName)
+}
+
+func (s *Server) handleSymbols(w http.ResponseWriter, r *http.Request) {
+	var conds []model.Cond
+	q := r.URL.Query()
+	if v := q.Get("class"); v != "" {
+		conds = append(conds, model.Cond{Column: "class", Op: "IN", Value: strings.Split(v, ",")})
+	}
+	if v := q.Get("symbol"); v != "" {
+		conds = append(conds, model.Cond{Column: "symbol", Op: "=", Value: strings.ToLower(v)})
+	}
+	if v := q.Get("name"); v != "" {
+		conds = append(conds, model.Cond{Column: "name", Op: "LIKE", Value: "%" + v + "%"})
+	}
+	s.serveQuery(w, r, model.RowQuery{
+		Table:   model.TableSymbolName.TableName,
+		Where:   conds,
+		OrderBy: []string{"symbol"},
+	})
+}
+
+func (s *Server) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	var conds []model.Cond
+	if v := r.URL.Query().Get("type"); v != "" {
+		conds = append(conds, model.Cond{Column: "block_type", Op: "=", Value: v})
+	}
+	s.serveQuery(w, r, model.RowQuery{
+		Table:   model.TableBlockInfo.TableName,
+		Where:   conds,
+		OrderBy: []string{"block_code"},
+	})
+}
+
+func (s *Server) handleBlockMembers(w http.ResponseWriter, r *http.Request) {
+	s.serveQuery(w, r, model.RowQuery{
+		Table:   model.TableBlockMember.TableName,
+		Where:   []model.Cond{{Column: "block_code", Op: "=", Value: r.PathValue("code")}},
+		OrderBy: []string{"stock_symbol"},
+	})
+}
+
+// handleCalendar 按 raw_holidays + 周末推算 [start, end] 内的交易日，
+// 默认取今年全年。结果是算出来的，分页在内存里做。
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePage(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(now.Year(), 12, 31, 0, 0, 0, 0, time.UTC)
+	q := r.URL.Query()
+	if v := q.Get("start"); v != "" {
+		if start, err = parseDate("start", v); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	if v := q.Get("end"); v != "" {
+		if end, err = parseDate("end", v); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	if end.Before(start) {
+		writeError(w, r, badRequest("end %s is before start %s", end.Format("2006-01-02"), start.Format("2006-01-02")))
+		return
+	}
+
+	holidays, err := s.db.GetHolidays()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	cal := workflow.NewTradingCalendar(holidays)
+
+	set := &model.RowSet{Columns: []string{"date"}}
+	skipped := 0
+	for d := start; !d.After(end) && len(set.Rows) <= p.limit; d = d.AddDate(0, 0, 1) {
+		if !cal.IsTradingDay(d) {
+			continue
+		}
+		if skipped < p.offset {
+			skipped++
+			continue
+		}
+		set.Rows = append(set.Rows, []any{d})
+	}
+	writeRows(w, p, set)
+}
+
+// handleMetrics 每次抓取时现查各表最新日期，外加进程内的下载量与主站测速。
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	reg := metrics.NewRegistry()
+	dates, err := workflow.LatestDates(s.db)
+	if err != nil {
+		slog.Warn("⚠️ 查询各表最新日期失败", "error", err)
+	}
+	metrics.RecordFreshness(reg, dates)
+	metrics.RecordProcess(reg)
+	reg.Handler().ServeHTTP(w, r)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	if errors.As(err, &he) {
+		status = he.status
+	} else {
+		slog.Error("💥 API 查询失败", "path", r.URL.Path, "error", err)
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
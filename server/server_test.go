@@ -0,0 +1,129 @@
+package server
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+)
+
+// fakeRepo 只实现 serve 用到的方法，其余调用会因 nil 接口 panic。
+type fakeRepo struct {
+	database.DataRepository
+	queries []model.RowQuery
+	class   string
+	rows    [][]any
+}
+
+func (f *fakeRepo) QueryRows(q model.RowQuery) (*model.RowSet, error) {
+	f.queries = append(f.queries, q)
+	if q.Table == model.TableSymbolClass.TableName {
+		if f.class == "" {
+			return &model.RowSet{Columns: []string{"class"}}, nil
+		}
+		return &model.RowSet{Columns: []string{"class"}, Rows: [][]any{{f.class}}}, nil
+	}
+	rows := f.rows
+	if q.Offset < len(rows) {
+		rows = rows[q.Offset:]
+	} else {
+		rows = nil
+	}
+	if q.Limit > 0 && len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+	}
+	return &model.RowSet{Columns: []string{"symbol", "date", "close"}, Rows: rows}, nil
+}
+
+func (f *fakeRepo) GetHolidays() ([]time.Time, error) {
+	return []time.Time{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, nil
+}
+
+func day(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+func get(t *testing.T, h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDailyPicksViewAndPaginates(t *testing.T) {
+	repo := &fakeRepo{class: model.ClassStock, rows: [][]any{
+		{"sz000001", day(2), 10.5}, {"sz000001", day(3), 10.6}, {"sz000001", day(4), 10.7},
+	}}
+	h := New(repo).Handler()
+
+	rec := get(t, h, "/api/v1/daily/SZ000001?fq=qfq&start=2024-01-02&limit=2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	q := repo.queries[len(repo.queries)-1]
+	if q.Table != model.ViewStockQFQ.Name || q.Limit != 3 || len(q.Where) != 2 {
+		t.Fatalf("unexpected query: %+v", q)
+	}
+
+	want := `{"columns":["symbol","date","close"],"data":[{"symbol":"sz000001","date":"2024-01-02","close":10.5},` +
+		`{"symbol":"sz000001","date":"2024-01-03","close":10.6}],"limit":2,"offset":0,"next_offset":2}` + "\n"
+	if rec.Body.String() != want {
+		t.Fatalf("body:\n%s\nwant:\n%s", rec.Body, want)
+	}
+}
+
+func TestDailyErrors(t *testing.T) {
+	h := New(&fakeRepo{class: model.ClassIndex}).Handler()
+	if rec := get(t, h, "/api/v1/daily/sh000001?fq=qfq"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("index qfq: status %d", rec.Code)
+	}
+	if rec := get(t, h, "/api/v1/daily/sh000001?start=2024-13-01"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad date: status %d", rec.Code)
+	}
+	if rec := get(t, New(&fakeRepo{}).Handler(), "/api/v1/daily/sh999999"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown symbol: status %d", rec.Code)
+	}
+}
+
+func TestCSVWithGzip(t *testing.T) {
+	repo := &fakeRepo{rows: [][]any{{"sh600000", day(2), 7.0}}}
+	rec := get(t, New(repo).Handler(), "/api/v1/basic?date=20240102&format=csv", "Accept-Encoding", "gzip")
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("response not gzipped")
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if want := "symbol,date,close\nsh600000,2024-01-02,7\n"; string(body) != want {
+		t.Fatalf("csv = %q, want %q", body, want)
+	}
+}
+
+func TestCalendarSkipsWeekendsAndHolidays(t *testing.T) {
+	rec := get(t, New(&fakeRepo{}).Handler(), "/api/v1/calendar?start=2024-01-01&end=2024-01-08&offset=1&limit=3")
+	var resp struct {
+		Data       []map[string]string `json:"data"`
+		NextOffset int                 `json:"next_offset"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range resp.Data {
+		got = append(got, r["date"])
+	}
+	if strings.Join(got, ",") != "2024-01-03,2024-01-04,2024-01-05" || resp.NextOffset != 4 {
+		t.Fatalf("calendar = %v next=%d", got, resp.NextOffset)
+	}
+}
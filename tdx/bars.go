@@ -0,0 +1,297 @@
+package tdx
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+// BarCategory 是 0x52d security bars 请求的 K 线周期，取值与 opentdx / pytdx 一致。
+type BarCategory uint16
+
+const (
+	Bar5Min   BarCategory = 0
+	Bar15Min  BarCategory = 1
+	Bar30Min  BarCategory = 2
+	Bar60Min  BarCategory = 3
+	BarWeekly BarCategory = 5
+	Bar1Min   BarCategory = 8
+	BarDaily  BarCategory = 9
+)
+
+// MaxBarsPerRequest 是服务端单次最多返回的 K 线条数。
+const MaxBarsPerRequest = 800
+
+// Bar 是一根在线 K 线。日线及以上周期 Datetime 为当日零点。
+type Bar struct {
+	Datetime time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   int64 // 股
+	Amount   float64
+}
+
+// intraday 报告周期是否为分钟级，决定日期字段的编码方式。
+func (c BarCategory) intraday() bool {
+	return c < 4 || c == 7 || c == Bar1Min
+}
+
+// symbolMarket 把 "sh600000" 拆成 (市场号, 裸代码)，与 marketCodeToSymbol 相反。
+func symbolMarket(symbol string) (uint16, string, error) {
+	if len(symbol) != 8 {
+		return 0, "", fmt.Errorf("invalid symbol %q", symbol)
+	}
+	code := symbol[2:]
+	switch strings.ToLower(symbol[:2]) {
+	case "sz":
+		return 0, code, nil
+	case "sh":
+		return 1, code, nil
+	case "bj":
+		return 2, code, nil
+	default:
+		return 0, "", fmt.Errorf("unsupported market in symbol %q", symbol)
+	}
+}
+
+// GetSecurityBars 取 symbol 的 K 线，start 为距最新一根的偏移 (0 = 最新)，count ≤ 800。
+// 返回按时间升序。需先 ConnectStandard + LoginStandard。
+func (c *OnlineClient) GetSecurityBars(symbol string, category BarCategory, start, count uint16) ([]Bar, error) {
+	market, code, err := symbolMarket(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if count > MaxBarsPerRequest {
+		count = MaxBarsPerRequest
+	}
+
+	// 0x52d: market(u16) + code(6) + category(u16) + 1(u16) + start(u16) + count(u16) + padding(10)
+	body := make([]byte, 26)
+	binary.LittleEndian.PutUint16(body[0:2], market)
+	copy(body[2:8], code)
+	binary.LittleEndian.PutUint16(body[8:10], uint16(category))
+	binary.LittleEndian.PutUint16(body[10:12], 1)
+	binary.LittleEndian.PutUint16(body[12:14], start)
+	binary.LittleEndian.PutUint16(body[14:16], count)
+
+	data, err := c.CallWithHead(0x0c, 0x52d, body)
+	if err != nil {
+		return nil, err
+	}
+	return parseSecurityBars(data, category, priceDivisor(symbol))
+}
+
+// priceDivisor 返回协议价格的除数。协议价格比 .day 文件多一位小数：
+// 股票 /1000，ETF / B 股 (.day 里 scale=1000) 要 /10000。
+func priceDivisor(symbol string) float64 {
+	return model.PriceScale(symbol) * 10
+}
+
+func parseSecurityBars(data []byte, category BarCategory, divisor float64) ([]Bar, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("security bars response too short: %d", len(data))
+	}
+	n := int(binary.LittleEndian.Uint16(data[0:2]))
+	pos := 2
+	bars := make([]Bar, 0, n)
+	var base int64
+
+	for i := 0; i < n; i++ {
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("security bars truncated at row %d", i)
+		}
+		dt, err := parseBarDatetime(data[pos:pos+4], category)
+		pos += 4
+		if err != nil {
+			return nil, err
+		}
+
+		var diffs [4]int64 // open, close, high, low
+		for j := range diffs {
+			if diffs[j], pos, err = readPrice(data, pos); err != nil {
+				return nil, fmt.Errorf("security bars row %d: %w", i, err)
+			}
+		}
+		if pos+8 > len(data) {
+			return nil, fmt.Errorf("security bars truncated at row %d", i)
+		}
+		vol := decodeVolume(binary.LittleEndian.Uint32(data[pos : pos+4]))
+		amount := decodeVolume(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		pos += 8
+
+		// 开盘价相对上一根收盘价差分，其余三价相对本根开盘价差分。
+		open := base + diffs[0]
+		bars = append(bars, Bar{
+			Datetime: dt,
+			Open:     float64(open) / divisor,
+			Close:    float64(open+diffs[1]) / divisor,
+			High:     float64(open+diffs[2]) / divisor,
+			Low:      float64(open+diffs[3]) / divisor,
+			// 协议成交量单位为手，换成股与 .day 文件一致。
+			Volume: int64(math.Round(vol)) * 100,
+			Amount: amount,
+		})
+		base = open + diffs[1]
+	}
+	return bars, nil
+}
+
+func parseBarDatetime(b []byte, category BarCategory) (time.Time, error) {
+	var year, month, day, hour, minute int
+	if category.intraday() {
+		zipDay := int(binary.LittleEndian.Uint16(b[0:2]))
+		minutes := int(binary.LittleEndian.Uint16(b[2:4]))
+		year = zipDay>>11 + 2004
+		month = (zipDay % 2048) / 100
+		day = (zipDay % 2048) % 100
+		hour, minute = minutes/60, minutes%60
+	} else {
+		v := int(binary.LittleEndian.Uint32(b))
+		year, month, day = v/10000, v%10000/100, v%100
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("invalid bar date %04d-%02d-%02d", year, month, day)
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, 0, 0, time.UTC), nil
+}
+
+// readPrice 读取变长有符号整数：首字节 bit7 续位、bit6 符号、低 6 位数据，
+// 后续字节 bit7 续位、低 7 位数据。
+func readPrice(data []byte, pos int) (int64, int, error) {
+	if pos >= len(data) {
+		return 0, pos, fmt.Errorf("price out of range")
+	}
+	b := data[pos]
+	v := int64(b & 0x3f)
+	negative := b&0x40 != 0
+	shift := 6
+	for b&0x80 != 0 {
+		pos++
+		if pos >= len(data) {
+			return 0, pos, fmt.Errorf("price out of range")
+		}
+		b = data[pos]
+		v += int64(b&0x7f) << shift
+		shift += 7
+	}
+	pos++
+	if negative {
+		v = -v
+	}
+	return v, pos, nil
+}
+
+// decodeVolume 解码通达信自定义的 4 字节浮点 (成交量 / 成交额)，算法同 pytdx get_volume。
+func decodeVolume(raw uint32) float64 {
+	if raw == 0 {
+		return 0
+	}
+	logPoint := int(raw >> 24)
+	hl := int(raw>>16) & 0xff
+	lh := int(raw>>8) & 0xff
+	ll := int(raw) & 0xff
+
+	ecx := logPoint*2 - 0x7f
+	edx := logPoint*2 - 0x86
+	esi := logPoint*2 - 0x8e
+	eax := logPoint*2 - 0x96
+
+	xmm6 := math.Pow(2, math.Abs(float64(ecx)))
+	if ecx < 0 {
+		xmm6 = 1 / xmm6
+	}
+
+	var xmm4 float64
+	if hl > 0x80 {
+		xmm4 = math.Pow(2, float64(edx))*128 + float64(hl&0x7f)*math.Pow(2, float64(edx+1))
+	} else if edx >= 0 {
+		xmm4 = math.Pow(2, float64(edx)) * float64(hl)
+	} else {
+		xmm4 = 1 / math.Pow(2, float64(edx)) * float64(hl)
+	}
+
+	xmm3 := math.Pow(2, float64(esi)) * float64(lh)
+	xmm1 := math.Pow(2, float64(eax)) * float64(ll)
+	if hl&0x80 != 0 {
+		xmm3 *= 2
+		xmm1 *= 2
+	}
+	return xmm6 + xmm4 + xmm3 + xmm1
+}
+
+// BarsToKlineDay 把日线 Bar 转成 model.KlineDay。
+func BarsToKlineDay(symbol string, bars []Bar) []model.KlineDay {
+	rows := make([]model.KlineDay, len(bars))
+	for i, b := range bars {
+		rows[i] = model.KlineDay{
+			Symbol: symbol,
+			Open:   b.Open,
+			High:   b.High,
+			Low:    b.Low,
+			Close:  b.Close,
+			Amount: b.Amount,
+			Volume: b.Volume,
+			Date:   b.Datetime,
+		}
+	}
+	return rows
+}
+
+// FetchOnlineDailyBars 逐个代码拉取在线日线，只保留 dates 中的日期。
+// 用于 g4day zip 缺失或尚未发布时补齐最近几个交易日，单连接串行请求。
+func FetchOnlineDailyBars(ctx context.Context, symbols []string, dates []time.Time) ([]model.KlineDay, error) {
+	if len(symbols) == 0 || len(dates) == 0 {
+		return nil, nil
+	}
+	want := make(map[string]bool, len(dates))
+	earliest := dates[0]
+	for _, d := range dates {
+		want[d.Format("2006-01-02")] = true
+		if d.Before(earliest) {
+			earliest = d
+		}
+	}
+	// 偏移 0 是最新一根：按自然日估算需要回溯的根数，多取几根兜底。
+	count := int(time.Since(earliest).Hours()/24) + 5
+	if count > MaxBarsPerRequest {
+		count = MaxBarsPerRequest
+	}
+
+	client := NewOnlineClient().WithContext(ctx)
+	if err := client.ConnectStandard(); err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	if err := client.LoginStandard(); err != nil {
+		return nil, err
+	}
+
+	log := utils.Logger(ctx)
+	var rows []model.KlineDay
+	for i, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		bars, err := client.GetSecurityBars(symbol, BarDaily, 0, uint16(count))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch bars for %s: %w", symbol, err)
+		}
+		for _, r := range BarsToKlineDay(symbol, bars) {
+			if want[r.Date.Format("2006-01-02")] {
+				rows = append(rows, r)
+			}
+		}
+		if (i+1)%1000 == 0 {
+			log.Debug("在线日线拉取进度", "done", i+1, "total", len(symbols))
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,100 @@
+package tdx
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// encodePrice 是 readPrice 的逆过程，仅测试用。
+func encodePrice(v int64) []byte {
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+	b := byte(v & 0x3f)
+	if neg {
+		b |= 0x40
+	}
+	v >>= 6
+	out := []byte{b}
+	for v > 0 {
+		out[len(out)-1] |= 0x80
+		out = append(out, byte(v&0x7f))
+		v >>= 7
+	}
+	return out
+}
+
+func TestReadPriceRoundTrip(t *testing.T) {
+	for _, v := range []int64{0, 1, -1, 63, 64, -8191, 123456, -9876543} {
+		buf := encodePrice(v)
+		got, pos, err := readPrice(buf, 0)
+		if err != nil || got != v || pos != len(buf) {
+			t.Fatalf("readPrice(%d) = %d, pos %d, err %v", v, got, pos, err)
+		}
+	}
+}
+
+func TestParseSecurityBarsDaily(t *testing.T) {
+	data := []byte{2, 0}
+	day := func(v uint32) []byte {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, v)
+		return b
+	}
+	zeroVol := make([]byte, 8)
+
+	// 第一根：开 10.00 收 10.50 高 10.80 低 9.90 (股票 /1000)
+	data = append(data, day(20240102)...)
+	for _, p := range []int64{10000, 500, 800, -100} {
+		data = append(data, encodePrice(p)...)
+	}
+	data = append(data, zeroVol...)
+	// 第二根：开盘价相对上一根收盘 10.50 差分 → 开 10.40 收 10.20 高 10.60 低 10.10
+	data = append(data, day(20240103)...)
+	for _, p := range []int64{-100, -200, 200, -300} {
+		data = append(data, encodePrice(p)...)
+	}
+	data = append(data, zeroVol...)
+
+	bars, err := parseSecurityBars(data, BarDaily, priceDivisor("sz000001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("got %d bars", len(bars))
+	}
+	want := []Bar{
+		{Datetime: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Open: 10, Close: 10.5, High: 10.8, Low: 9.9},
+		{Datetime: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Open: 10.4, Close: 10.2, High: 10.6, Low: 10.1},
+	}
+	for i := range want {
+		if bars[i] != want[i] {
+			t.Fatalf("bar %d = %+v, want %+v", i, bars[i], want[i])
+		}
+	}
+}
+
+func TestParseBarDatetimeIntraday(t *testing.T) {
+	b := make([]byte, 4)
+	// 2024-01-02: (2024-2004)<<11 + 1*100 + 2
+	binary.LittleEndian.PutUint16(b[0:2], uint16(20<<11+102))
+	binary.LittleEndian.PutUint16(b[2:4], 9*60+31)
+	got, err := parseBarDatetime(b, Bar1Min)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 2, 9, 31, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestSymbolMarket(t *testing.T) {
+	if m, code, err := symbolMarket("bj920001"); err != nil || m != 2 || code != "920001" {
+		t.Fatalf("symbolMarket = %d %s %v", m, code, err)
+	}
+	if _, _, err := symbolMarket("hk00700"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
 	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
@@ -31,6 +32,14 @@ type BlockListItem struct {
 	Query  BlockType
 }
 
+// BlockMemberWorkers 是并发拉取板块成分股的连接数。
+var BlockMemberWorkers = 4
+
+type blockMemberJob struct {
+	item      BlockListItem
+	blockCode string
+}
+
 type BlockMemberItem struct {
 	Market uint16
 	Code   string
@@ -38,7 +47,7 @@ type BlockMemberItem struct {
 }
 
 func FetchOnlineBlocks(ctx context.Context) ([]model.BlockInfo, []model.BlockMember, error) {
-	client := NewOnlineClient()
+	client := NewOnlineClient().WithContext(ctx)
 	if err := client.Connect(); err != nil {
 		return nil, nil, err
 	}
@@ -48,6 +57,8 @@ func FetchOnlineBlocks(ctx context.Context) ([]model.BlockInfo, []model.BlockMem
 	infoByCode := make(map[string]model.BlockInfo)
 	memberSet := make(map[string]model.BlockMember)
 
+	// 先串行取完各类板块列表，再把成分股请求分摊到连接池并发拉取。
+	var jobs []blockMemberJob
 	for _, qt := range queryTypes {
 		if err := ctx.Err(); err != nil {
 			return nil, nil, err
@@ -56,26 +67,61 @@ func FetchOnlineBlocks(ctx context.Context) ([]model.BlockInfo, []model.BlockMem
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to fetch block list %s: %w", qt.blockType(), err)
 		}
+		utils.Logger(ctx).Debug("板块列表", "block_type", qt.blockType(), "level", qt.level(), "blocks", len(blocks))
 		for _, blockItem := range blocks {
 			block := blockItem.toBlockInfo()
 			if _, exists := infoByCode[block.BlockCode]; !exists {
 				infoByCode[block.BlockCode] = block
 			}
+			jobs = append(jobs, blockMemberJob{item: blockItem, blockCode: block.BlockCode})
+		}
+	}
 
-			members, err := client.GetBlockMembers(blockItem.Code, 5000)
+	pool, err := NewClientPool(client, min(BlockMemberWorkers, max(len(jobs), 1)))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer pool.Close()
+
+	pipeline := utils.NewPipeline[blockMemberJob, model.BlockMember](utils.WithConcurrency(pool.Size()))
+	result, err := pipeline.Run(ctx, jobs,
+		func(ctx context.Context, job blockMemberJob) ([]model.BlockMember, error) {
+			c, err := pool.Get(ctx)
 			if err != nil {
-				return nil, nil, fmt.Errorf("failed to fetch members for %s %s: %w", blockItem.Code, blockItem.Name, err)
+				return nil, err
 			}
+			defer pool.Put(c)
+
+			members, err := c.GetBlockMembers(job.item.Code, 5000)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch members for %s %s: %w", job.item.Code, job.item.Name, err)
+			}
+			rows := make([]model.BlockMember, 0, len(members))
 			for _, m := range members {
-				symbol, ok := marketCodeToSymbol(m.Market, m.Code)
-				if !ok {
-					continue
+				if symbol, ok := marketCodeToSymbol(m.Market, m.Code); ok {
+					rows = append(rows, model.BlockMember{StockSymbol: symbol, BlockCode: job.blockCode})
 				}
-				member := model.BlockMember{StockSymbol: symbol, BlockCode: block.BlockCode}
+			}
+			return rows, nil
+		},
+		// consume 只在单个 goroutine 中调用，memberSet 无需加锁。
+		func(rows []model.BlockMember) error {
+			for _, member := range rows {
 				memberSet[member.StockSymbol+"\x00"+member.BlockCode] = member
 			}
-		}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if result.HasErrors() {
+		return nil, nil, result.FirstError()
 	}
+	utils.Logger(ctx).Debug("板块成分拉取完成", "blocks", len(jobs), "workers", pool.Size(), "duration", result.Duration)
 
 	infos := make([]model.BlockInfo, 0, len(infoByCode))
 	for _, v := range infoByCode {
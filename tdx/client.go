@@ -3,31 +3,48 @@ package tdx
 import (
 	"bytes"
 	"compress/zlib"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"net"
 	"sort"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/jing2uo/tdx2db/utils"
 )
 
 const responseHeaderLen = 16
 
-var macHosts = []struct {
+const (
+	// DefaultCallTimeout 是单次请求 (写 + 读) 的默认超时，ctx 截止时间更早时以 ctx 为准。
+	DefaultCallTimeout = 15 * time.Second
+	// HeartbeatInterval 内没有请求时，后台发一次心跳保持长连接。
+	HeartbeatInterval = 30 * time.Second
+	// maxCallAttempts 限制一次请求在 I/O 失败后换主站重试的总次数。
+	maxCallAttempts = 3
+)
+
+// Host 是一个通达信主站。
+type Host struct {
 	Name string
 	Addr string
 	Port string
-}{
+}
+
+func (h Host) address() string {
+	return net.JoinHostPort(h.Addr, h.Port)
+}
+
+var macHosts = []Host{
 	{"行情主站1", "121.36.248.138", "7709"},
 	{"行情主站2", "123.60.47.136", "7709"},
 	{"行情主站3", "121.37.207.165", "7709"},
 }
 
-var standardHosts = []struct {
-	Name string
-	Addr string
-	Port string
-}{
+var standardHosts = []Host{
 	{"通达信深圳双线主站1", "110.41.147.114", "7709"},
 	{"通达信深圳双线主站2", "110.41.2.72", "7709"},
 	{"通达信深圳双线主站3", "110.41.4.4", "7709"},
@@ -37,60 +54,257 @@ var standardHosts = []struct {
 	{"通达信北京双线主站1", "121.36.54.217", "7709"},
 }
 
+// SetHosts 覆盖内置主站列表：mac 用于板块 (Connect)，standard 用于代码 / 行情 (ConnectStandard)。
+// 传空保留对应的内置列表。需在创建客户端之前调用。
+func SetHosts(mac, standard []Host) {
+	if len(mac) > 0 {
+		macHosts = mac
+	}
+	if len(standard) > 0 {
+		standardHosts = standard
+	}
+}
+
+// ParseHosts 解析 "ip:port" 或 "name=ip:port"，端口省略时为 7709。
+func ParseHosts(specs []string) ([]Host, error) {
+	hosts := make([]Host, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, addr := "", spec
+		if i := strings.Index(spec, "="); i >= 0 {
+			name, addr = spec[:i], spec[i+1:]
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			host, port = addr, "7709"
+		}
+		if host == "" {
+			return nil, fmt.Errorf("invalid TDX host %q", spec)
+		}
+		if name == "" {
+			name = host
+		}
+		hosts = append(hosts, Host{Name: name, Addr: host, Port: port})
+	}
+	return hosts, nil
+}
+
+// HostProbe 记录 connectToHosts 对单个主站的测速结果, 供 metrics 导出。
+type HostProbe struct {
+	Name    string
+	Addr    string
+	Up      bool
+	Latency time.Duration
+}
+
+var (
+	hostProbes   = map[string]HostProbe{}
+	hostProbesMu sync.Mutex
+)
+
+// HostProbes 返回本进程最近一次测速得到的各主站结果, 按地址排序。
+func HostProbes() []HostProbe {
+	hostProbesMu.Lock()
+	defer hostProbesMu.Unlock()
+	out := make([]HostProbe, 0, len(hostProbes))
+	for _, p := range hostProbes {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Addr < out[j].Addr })
+	return out
+}
+
+func recordHostProbe(p HostProbe) {
+	hostProbesMu.Lock()
+	defer hostProbesMu.Unlock()
+	hostProbes[p.Addr] = p
+}
+
+// OnlineClient 是通达信在线协议客户端，请求串行执行。
+// I/O 出错时自动切到测速排名的下一个主站并重试该请求；空闲过久时后台发心跳。
 type OnlineClient struct {
-	conn net.Conn
+	// Timeout 是单次请求超时，为 0 时用 DefaultCallTimeout。
+	Timeout time.Duration
+
+	mu       sync.Mutex
+	ctx      context.Context
+	conn     net.Conn
+	standard bool     // 标准行情主站 (head 0x0c)，决定心跳报文与重连后是否重新登录
+	loggedIn bool     // 已 LoginStandard，重连后需重新登录
+	replay   bool     // 回放模式不发心跳，避免录制文件外的请求
+	addrs    []string // 按测速排序的候选主站
+	current  int
+	lastUsed time.Time
+	stopBeat chan struct{}
 }
 
 func NewOnlineClient() *OnlineClient {
-	return &OnlineClient{}
+	return &OnlineClient{ctx: context.Background()}
+}
+
+// WithContext 把请求的截止时间与取消绑定到 ctx，返回 c 本身。
+func (c *OnlineClient) WithContext(ctx context.Context) *OnlineClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ctx = ctx
+	return c
 }
 
 func (c *OnlineClient) Connect() error {
-	return c.connectToHosts(macHosts)
+	return c.connectToHosts(macHosts, false)
 }
 
 func (c *OnlineClient) ConnectStandard() error {
-	return c.connectToHosts(standardHosts)
+	return c.connectToHosts(standardHosts, true)
 }
 
-func (c *OnlineClient) connectToHosts(hosts []struct {
-	Name string
-	Addr string
-	Port string
-}) error {
+func (c *OnlineClient) connectToHosts(hosts []Host, standard bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.conn != nil {
 		return nil
 	}
+	c.standard = standard
+
+	addr, err := replayAddr()
+	if err != nil {
+		return err
+	}
+	if addr != "" {
+		c.addrs, c.replay = []string{addr}, true
+	} else if c.addrs, err = probeHosts(hosts); err != nil {
+		return err
+	}
+
+	if err := c.dialFrom(0); err != nil {
+		return err
+	}
+	if !c.replay {
+		c.stopBeat = make(chan struct{})
+		go c.heartbeat(c.stopBeat)
+	}
+	return nil
+}
 
+// probeHosts 对每个主站做一次 TCP 握手测速，返回按延迟升序的可用地址。
+func probeHosts(hosts []Host) ([]string, error) {
 	type candidate struct {
 		addr string
 		dur  time.Duration
 	}
 	var candidates []candidate
 	for _, h := range hosts {
-		addr := net.JoinHostPort(h.Addr, h.Port)
+		addr := h.address()
 		start := time.Now()
 		conn, err := net.DialTimeout("tcp", addr, time.Second)
 		if err != nil {
+			recordHostProbe(HostProbe{Name: h.Name, Addr: addr})
 			continue
 		}
 		_ = conn.Close()
-		candidates = append(candidates, candidate{addr: addr, dur: time.Since(start)})
+		dur := time.Since(start)
+		recordHostProbe(HostProbe{Name: h.Name, Addr: addr, Up: true, Latency: dur})
+		candidates = append(candidates, candidate{addr: addr, dur: dur})
 	}
 	if len(candidates) == 0 {
-		return fmt.Errorf("no available TDX online server")
+		return nil, fmt.Errorf("no available TDX online server")
 	}
 	sort.Slice(candidates, func(i, j int) bool { return candidates[i].dur < candidates[j].dur })
 
-	conn, err := net.DialTimeout("tcp", candidates[0].addr, 5*time.Second)
-	if err != nil {
-		return fmt.Errorf("failed to connect TDX server %s: %w", candidates[0].addr, err)
+	addrs := make([]string, len(candidates))
+	for i, cand := range candidates {
+		addrs[i] = cand.addr
+	}
+	return addrs, nil
+}
+
+// dialFrom 从 addrs[start] 起依次尝试连接，绕回开头，直到成功。调用方持有 c.mu。
+func (c *OnlineClient) dialFrom(start int) error {
+	var lastErr error
+	for i := range c.addrs {
+		idx := (start + i) % len(c.addrs)
+		conn, err := net.DialTimeout("tcp", c.addrs[idx], 5*time.Second)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		c.conn, c.current, c.lastUsed = conn, idx, time.Now()
+		return nil
+	}
+	return fmt.Errorf("failed to connect TDX server: %w", lastErr)
+}
+
+// reconnect 丢弃当前连接，切到下一个主站；已登录的标准主站会重新登录。调用方持有 c.mu。
+func (c *OnlineClient) reconnect() error {
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	if len(c.addrs) == 0 {
+		return fmt.Errorf("TDX online client is not connected")
 	}
-	c.conn = conn
+	if err := c.dialFrom(c.current + 1); err != nil {
+		return err
+	}
+	if c.loggedIn {
+		if _, _, err := c.exchange(c.ctx, 0x0c, 0x0d, []byte{1}); err != nil {
+			return fmt.Errorf("failed to login TDX standard server: %w", err)
+		}
+	}
+	utils.Logger(c.ctx).Info("🔁 通达信主站已切换", "addr", c.addrs[c.current])
 	return nil
 }
 
+// heartbeat 在连接空闲满 HeartbeatInterval 时发一次轻量请求，失败则切换主站。
+func (c *OnlineClient) heartbeat(stop <-chan struct{}) {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.Lock()
+		if c.conn != nil && time.Since(c.lastUsed) >= HeartbeatInterval {
+			head, msgID, body := c.heartbeatFrame()
+			if _, _, err := c.exchange(context.Background(), head, msgID, body); err != nil {
+				utils.Logger(c.ctx).Warn("⚠️ 通达信心跳失败，切换主站", "addr", c.addrs[c.current], "error", err)
+				if err := c.reconnect(); err != nil {
+					utils.Logger(c.ctx).Warn("⚠️ 通达信主站切换失败", "error", err)
+				}
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// heartbeatFrame 标准主站用证券数量查询 (0x44e)，mac 主站取一条概念板块列表。
+func (c *OnlineClient) heartbeatFrame() (byte, uint16, []byte) {
+	if c.standard {
+		body := make([]byte, 6)
+		binary.LittleEndian.PutUint32(body[2:6], 0x0133c775)
+		return 0x0c, 0x44e, body
+	}
+	body := make([]byte, 18)
+	binary.LittleEndian.PutUint16(body[0:2], 1)
+	binary.LittleEndian.PutUint16(body[2:4], uint16(BlockTypeGN))
+	body[5] = 1
+	binary.LittleEndian.PutUint16(body[8:10], 1)
+	return 1, 0x1231, body
+}
+
 func (c *OnlineClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopBeat != nil {
+		close(c.stopBeat)
+		c.stopBeat = nil
+	}
 	if c.conn == nil {
 		return nil
 	}
@@ -103,11 +317,74 @@ func (c *OnlineClient) Call(msgID uint16, body []byte) ([]byte, error) {
 	return c.CallWithHead(1, msgID, body)
 }
 
+// CallWithHead 发送一次请求并返回解压后的响应体。
+// I/O 失败时切到下一个主站重试，最多 maxCallAttempts 次；ctx 取消或超时时立即返回。
 func (c *OnlineClient) CallWithHead(head byte, msgID uint16, body []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.conn == nil {
 		return nil, fmt.Errorf("TDX online client is not connected")
 	}
 
+	var err error
+	for attempt := 0; attempt < maxCallAttempts; attempt++ {
+		if attempt > 0 {
+			utils.Logger(c.ctx).Warn("⚠️ 通达信请求失败，换主站重试",
+				"msg_id", fmt.Sprintf("0x%x", msgID), "attempt", attempt, "error", err)
+			if rerr := c.reconnect(); rerr != nil {
+				return nil, fmt.Errorf("%w (reconnect: %v)", err, rerr)
+			}
+		}
+
+		var out []byte
+		var retryable bool
+		out, retryable, err = c.exchange(c.ctx, head, msgID, body)
+		if err == nil {
+			rec, err := activeRecorder()
+			if err != nil {
+				return nil, err
+			}
+			if rec != nil {
+				if err := rec.Record(Frame{Head: head, MsgID: msgID, Request: body, Response: out}); err != nil {
+					return nil, fmt.Errorf("failed to record TDX frame: %w", err)
+				}
+			}
+			return out, nil
+		}
+		if ctxErr := c.ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if !retryable {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// exchange 在当前连接上完成一次请求 / 响应。retryable 表示连接层错误，换主站可能恢复。
+// 调用方持有 c.mu。
+func (c *OnlineClient) exchange(ctx context.Context, head byte, msgID uint16, body []byte) ([]byte, bool, error) {
+	conn := c.conn
+	if conn == nil {
+		return nil, true, fmt.Errorf("TDX online client is not connected")
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCallTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, true, err
+	}
+	// ctx 取消时把截止时间提前到现在，打断阻塞中的读写。
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+	c.lastUsed = time.Now()
+
 	payload := make([]byte, 2+len(body))
 	binary.LittleEndian.PutUint16(payload[0:2], msgID)
 	copy(payload[2:], body)
@@ -120,31 +397,31 @@ func (c *OnlineClient) CallWithHead(head byte, msgID uint16, body []byte) ([]byt
 	binary.LittleEndian.PutUint16(packet[8:10], uint16(len(payload)))
 	copy(packet[10:], payload)
 
-	if _, err := c.conn.Write(packet); err != nil {
-		return nil, fmt.Errorf("failed to send TDX request 0x%x: %w", msgID, err)
+	if _, err := conn.Write(packet); err != nil {
+		return nil, true, fmt.Errorf("failed to send TDX request 0x%x: %w", msgID, err)
 	}
 
 	header := make([]byte, responseHeaderLen)
-	if _, err := io.ReadFull(c.conn, header); err != nil {
-		return nil, fmt.Errorf("failed to read TDX response header: %w", err)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, true, fmt.Errorf("failed to read TDX response header: %w", err)
 	}
 	zippedSize := binary.LittleEndian.Uint16(header[12:14])
 	unzipSize := binary.LittleEndian.Uint16(header[14:16])
 	bodyBuf := make([]byte, zippedSize)
-	if _, err := io.ReadFull(c.conn, bodyBuf); err != nil {
-		return nil, fmt.Errorf("failed to read TDX response body: %w", err)
+	if _, err := io.ReadFull(conn, bodyBuf); err != nil {
+		return nil, true, fmt.Errorf("failed to read TDX response body: %w", err)
 	}
 	if zippedSize == unzipSize {
-		return bodyBuf, nil
+		return bodyBuf, false, nil
 	}
 	zr, err := zlib.NewReader(bytes.NewReader(bodyBuf))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create zlib reader: %w", err)
+		return nil, false, fmt.Errorf("failed to create zlib reader: %w", err)
 	}
 	defer zr.Close()
 	out, err := io.ReadAll(zr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decompress TDX response: %w", err)
+		return nil, false, fmt.Errorf("failed to decompress TDX response: %w", err)
 	}
-	return out, nil
+	return out, false, nil
 }
@@ -0,0 +1,95 @@
+package tdx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseHosts(t *testing.T) {
+	got, err := ParseHosts([]string{"sz=110.41.147.114:7709", " 1.2.3.4 ", "", "5.6.7.8:7711"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Host{
+		{Name: "sz", Addr: "110.41.147.114", Port: "7709"},
+		{Name: "1.2.3.4", Addr: "1.2.3.4", Port: "7709"},
+		{Name: "5.6.7.8", Addr: "5.6.7.8", Port: "7711"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseHosts = %+v, want %+v", got, want)
+	}
+	if _, err := ParseHosts([]string{"name=:7709"}); err == nil {
+		t.Fatal("expected error for empty host")
+	}
+}
+
+// echoResponder 原样返回请求体。
+func echoResponder(head byte, msgID uint16, body []byte) ([]byte, bool) {
+	return append([]byte(nil), body...), true
+}
+
+func TestCallFailsOverToNextHost(t *testing.T) {
+	bad, err := NewFakeServer(func(byte, uint16, []byte) ([]byte, bool) { return nil, false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	good, err := NewFakeServer(echoResponder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer good.Close()
+
+	c := NewOnlineClient()
+	c.standard = true
+	c.addrs = []string{bad.Addr(), good.Addr()}
+	if err := c.dialFrom(0); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	out, err := c.CallWithHead(0x0c, 0x44d, []byte{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, []byte{1, 2, 3}) {
+		t.Fatalf("unexpected response %v", out)
+	}
+	if c.addrs[c.current] != good.Addr() {
+		t.Fatalf("client still on %s", c.addrs[c.current])
+	}
+}
+
+func TestCallHonorsContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	stalled, err := NewFakeServer(func(byte, uint16, []byte) ([]byte, bool) {
+		<-release
+		return nil, false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stalled.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c := NewOnlineClient().WithContext(ctx)
+	c.addrs = []string{stalled.Addr()}
+	if err := c.dialFrom(0); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	start := time.Now()
+	_, err = c.CallWithHead(0x0c, 0x44d, []byte{1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("call took %s, deadline not applied", time.Since(start))
+	}
+}
@@ -1,6 +1,7 @@
 package tdx
 
 import (
+	"context"
 	"embed"
 	"errors"
 	"fmt"
@@ -9,6 +10,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"time"
+
+	"github.com/jing2uo/tdx2db/utils"
 )
 
 //go:embed  embed/*
@@ -21,7 +24,7 @@ var startDateStr = "19901201"
 // Linux/amd64. Everywhere else (Windows, macOS, and non-amd64 Linux such as
 // arm64), "day" uses the native Go implementation (NativeDayMerge), and
 // "min"/"tick" print a notice and skip, since only "day" has a native port.
-func DatatoolCreate(cacheDir, subCommand string, endDate time.Time) error {
+func DatatoolCreate(ctx context.Context, cacheDir, subCommand string, endDate time.Time) error {
 	switch subCommand {
 	case "day", "min", "tick":
 	default:
@@ -33,7 +36,8 @@ func DatatoolCreate(cacheDir, subCommand string, endDate time.Time) error {
 		if subCommand == "day" {
 			return NativeDayMerge(filepath.Join(cacheDir, "vipdoc"))
 		}
-		fmt.Printf("⚠️  %s 子命令暂不支持 %s/%s，已跳过\n", subCommand, runtime.GOOS, runtime.GOARCH)
+		utils.Logger(ctx).Warn(fmt.Sprintf("⚠️  %s 子命令暂不支持 %s/%s，已跳过", subCommand, runtime.GOOS, runtime.GOARCH),
+			"subcommand", subCommand, "os", runtime.GOOS, "arch", runtime.GOARCH)
 		return nil
 	}
 
@@ -0,0 +1,226 @@
+package tdx
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+// 通达信专业财务数据：gpcw.txt 列出全部 gpcwYYYYMMDD.zip 及其 hash，
+// 每个 zip 内是一期财报的 gpcwYYYYMMDD.dat。
+const financeBaseURL = "http://down.tdx.com.cn:8001/tdxfin/"
+
+// announceDateField 是 gpcw 中"财报公告日期"的字段编号，值为 yymmdd。
+const announceDateField = 314
+
+// FinanceFileInfo 是 gpcw.txt 中的一行。
+type FinanceFileInfo struct {
+	Filename   string
+	Hash       string
+	Size       int64
+	ReportDate time.Time
+}
+
+// FetchFinanceFileList 下载并解析 gpcw.txt，按报告期升序返回。
+func FetchFinanceFileList(ctx context.Context, tempDir string) ([]FinanceFileInfo, error) {
+	listPath := filepath.Join(tempDir, "gpcw.txt")
+	status, err := utils.DownloadFileWithOptions(ctx, financeBaseURL+"gpcw.txt", listPath, utils.DownloadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download gpcw.txt: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to download gpcw.txt: status %d", status)
+	}
+	return ParseFinanceFileList(listPath)
+}
+
+// ParseFinanceFileList 解析 "gpcw20231231.zip,<hash>,<size>" 格式的文件列表，跳过无法识别的行。
+func ParseFinanceFileList(path string) ([]FinanceFileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open finance file list: %w", err)
+	}
+	defer f.Close()
+
+	var files []FinanceFileInfo
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		parts := strings.Split(strings.TrimSpace(sc.Text()), ",")
+		if len(parts) < 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		date, ok := financeReportDate(name)
+		if !ok {
+			continue
+		}
+		info := FinanceFileInfo{Filename: name, Hash: strings.TrimSpace(parts[1]), ReportDate: date}
+		if len(parts) > 2 {
+			info.Size, _ = strconv.ParseInt(strings.TrimSpace(parts[2]), 10, 64)
+		}
+		files = append(files, info)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read finance file list: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ReportDate.Before(files[j].ReportDate) })
+	return files, nil
+}
+
+// financeReportDate 从 gpcwYYYYMMDD.zip 取报告期。
+func financeReportDate(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, "gpcw") || !strings.HasSuffix(name, ".zip") {
+		return time.Time{}, false
+	}
+	d, err := time.Parse("20060102", strings.TrimSuffix(strings.TrimPrefix(name, "gpcw"), ".zip"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// DownloadFinanceFile 下载并解压一期 gpcw zip，返回 .dat 路径。
+func DownloadFinanceFile(ctx context.Context, info FinanceFileInfo, tempDir string) (string, error) {
+	zipPath := filepath.Join(tempDir, info.Filename)
+	status, err := utils.DownloadFileWithOptions(ctx, financeBaseURL+info.Filename, zipPath, utils.DownloadOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", info.Filename, err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: status %d", info.Filename, status)
+	}
+	defer os.Remove(zipPath)
+
+	unzipDir := filepath.Join(tempDir, "gpcw-temp")
+	if err := utils.UnzipFile(zipPath, unzipDir); err != nil {
+		return "", fmt.Errorf("failed to unzip %s: %w", info.Filename, err)
+	}
+	return filepath.Join(unzipDir, strings.TrimSuffix(info.Filename, ".zip")+".dat"), nil
+}
+
+// DecodeFinanceFile 解析 gpcw .dat：
+//
+//	头部 20 字节: int16 + 报告期 u32 + 证券数 u16 + u32 + 每条记录字节数 u32 + u32
+//	索引 11 字节 * 证券数: 代码 6s + 1 字节 + 记录偏移 u32
+//	记录: float32 * (记录字节数 / 4)，字段编号从 1 开始
+func DecodeFinanceFile(path string) ([]model.Finance, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read finance file: %w", err)
+	}
+	return decodeFinance(data)
+}
+
+func decodeFinance(data []byte) ([]model.Finance, error) {
+	const headerLen, indexLen = 20, 11
+	if len(data) < headerLen {
+		return nil, fmt.Errorf("finance file too short: %d", len(data))
+	}
+	reportDate, err := fastParseDate(binary.LittleEndian.Uint32(data[2:6]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid finance report date: %w", err)
+	}
+	reportDate = time.Date(reportDate.Year(), reportDate.Month(), reportDate.Day(), 0, 0, 0, 0, time.UTC)
+	count := int(binary.LittleEndian.Uint16(data[6:8]))
+	recordSize := int(binary.LittleEndian.Uint32(data[12:16]))
+	fields := min(recordSize/4, model.FinanceFieldCount)
+
+	rows := make([]model.Finance, 0, count)
+	for i := 0; i < count; i++ {
+		idx := headerLen + i*indexLen
+		if idx+indexLen > len(data) {
+			return nil, fmt.Errorf("finance index truncated at row %d", i)
+		}
+		code := strings.TrimRight(string(data[idx:idx+6]), "\x00 ")
+		offset := int(binary.LittleEndian.Uint32(data[idx+7 : idx+11]))
+		if offset+fields*4 > len(data) {
+			return nil, fmt.Errorf("finance record for %s out of range", code)
+		}
+
+		symbol, ok := model.SymbolFromCode(code)
+		if !ok {
+			continue
+		}
+		row := model.Finance{Symbol: symbol, ReportDate: reportDate, N: fields}
+		for f := 0; f < fields; f++ {
+			v := math.Float32frombits(binary.LittleEndian.Uint32(data[offset+f*4:]))
+			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+				v = 0
+			}
+			row.Fields[f] = v
+		}
+		if fields >= announceDateField {
+			row.AnnounceDate = parseAnnounceDate(row.Fields[announceDateField-1], reportDate)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseAnnounceDate 把 yymmdd 转成日期；不在报告期之后两年内的值视为无效。
+func parseAnnounceDate(v float32, reportDate time.Time) time.Time {
+	n := int(v)
+	if n <= 0 {
+		return time.Time{}
+	}
+	d := time.Date(2000+n/10000, time.Month(n%10000/100), n%100, 0, 0, 0, 0, time.UTC)
+	if n/10000 > 80 {
+		d = d.AddDate(-100, 0, 0)
+	}
+	if d.Month() != time.Month(n%10000/100) || d.Before(reportDate) || d.After(reportDate.AddDate(2, 0, 0)) {
+		return time.Time{}
+	}
+	return d
+}
+
+// WriteFinanceCSV 按 model.TableFinance 的列顺序写 CSV，缺失字段与未知公告日期留空 (NULL)。
+func WriteFinanceCSV(path string, rows []model.Finance) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create finance csv: %w", err)
+	}
+	w := bufio.NewWriterSize(f, 1<<20)
+
+	header := make([]string, len(model.TableFinance.Columns))
+	for i, c := range model.TableFinance.Columns {
+		header[i] = c.Name
+	}
+	w.WriteString(strings.Join(header, ","))
+	w.WriteByte('\n')
+
+	buf := make([]byte, 0, 64)
+	for _, r := range rows {
+		w.WriteString(r.Symbol)
+		w.WriteByte(',')
+		w.WriteString(r.ReportDate.Format("2006-01-02"))
+		w.WriteByte(',')
+		if !r.AnnounceDate.IsZero() {
+			w.WriteString(r.AnnounceDate.Format("2006-01-02"))
+		}
+		for i := 0; i < model.FinanceFieldCount; i++ {
+			w.WriteByte(',')
+			if i < r.N {
+				buf = strconv.AppendFloat(buf[:0], float64(r.Fields[i]), 'g', -1, 32)
+				w.Write(buf)
+			}
+		}
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write finance csv: %w", err)
+	}
+	return f.Close()
+}
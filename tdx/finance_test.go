@@ -0,0 +1,111 @@
+package tdx
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jing2uo/tdx2db/model"
+)
+
+// buildFinanceDat 按 gpcw 布局拼一个 .dat，每只证券的字段 i (从 1 起) 取 values[code](i)。
+func buildFinanceDat(reportDate uint32, fields int, codes []string, value func(code string, field int) float32) []byte {
+	const headerLen, indexLen = 20, 11
+	recordSize := fields * 4
+	data := make([]byte, headerLen+indexLen*len(codes)+recordSize*len(codes))
+	binary.LittleEndian.PutUint32(data[2:6], reportDate)
+	binary.LittleEndian.PutUint16(data[6:8], uint16(len(codes)))
+	binary.LittleEndian.PutUint32(data[12:16], uint32(recordSize))
+	for i, code := range codes {
+		idx := headerLen + i*indexLen
+		offset := headerLen + indexLen*len(codes) + i*recordSize
+		copy(data[idx:idx+6], code)
+		binary.LittleEndian.PutUint32(data[idx+7:idx+11], uint32(offset))
+		for f := 1; f <= fields; f++ {
+			binary.LittleEndian.PutUint32(data[offset+(f-1)*4:], math.Float32bits(value(code, f)))
+		}
+	}
+	return data
+}
+
+func TestDecodeFinance(t *testing.T) {
+	data := buildFinanceDat(20240331, 320, []string{"600000", "000001", "999999"}, func(code string, f int) float32 {
+		switch f {
+		case 1:
+			return 0.25
+		case announceDateField:
+			if code == "600000" {
+				return 240427
+			}
+			return 990101 // 1999 年，早于报告期，视为无效
+		}
+		return float32(f)
+	})
+
+	rows, err := decodeFinance(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2 (unknown code skipped)", len(rows))
+	}
+	r := rows[0]
+	if r.Symbol != "sh600000" || !r.ReportDate.Equal(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)) || r.N != 320 {
+		t.Fatalf("unexpected row header %+v", r.Symbol)
+	}
+	if r.Fields[0] != 0.25 || r.Fields[94] != 95 {
+		t.Fatalf("unexpected fields f001=%v f095=%v", r.Fields[0], r.Fields[94])
+	}
+	if !r.AnnounceDate.Equal(time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("announce date = %v", r.AnnounceDate)
+	}
+	if rows[1].Symbol != "sz000001" || !rows[1].AnnounceDate.IsZero() {
+		t.Fatalf("unexpected second row %s %v", rows[1].Symbol, rows[1].AnnounceDate)
+	}
+
+	if _, err := decodeFinance(data[:40]); err == nil {
+		t.Fatal("expected error for truncated file")
+	}
+}
+
+func TestParseFinanceFileList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gpcw.txt")
+	content := "gpcw20240331.zip,abc,100\r\ngpcw20231231.zip,def,200\nbad line\ngpcw.txt,x,1\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ParseFinanceFileList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Filename != "gpcw20231231.zip" || files[1].Hash != "abc" || files[1].Size != 100 {
+		t.Fatalf("unexpected files %+v", files)
+	}
+}
+
+func TestWriteFinanceCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "finance.csv")
+	row := model.Finance{Symbol: "sh600000", ReportDate: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), N: 2}
+	row.Fields[0], row.Fields[1] = 0.1, 1.5e9
+	if err := WriteFinanceCSV(path, []model.Finance{row}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "symbol,report_date,announce_date,f001,f002,f003") {
+		t.Fatalf("unexpected header %q", lines[0][:40])
+	}
+	if got := strings.Count(lines[1], ","); got != model.FinanceFieldCount+2 {
+		t.Fatalf("row has %d separators", got)
+	}
+	if !strings.HasPrefix(lines[1], "sh600000,2024-03-31,,0.1,1.5e+09,,") {
+		t.Fatalf("unexpected row %q", lines[1][:40])
+	}
+}
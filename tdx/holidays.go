@@ -2,6 +2,7 @@ package tdx
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -101,7 +102,7 @@ func ReadHolidays(filePath string) ([]model.Holiday, error) {
 	for _, dateStr := range allHolidays {
 		date, err := time.Parse("2006-01-02", dateStr)
 		if err != nil {
-			fmt.Printf("⚠️  解析日期失败 %s: %v\n", dateStr, err)
+			slog.Warn(fmt.Sprintf("⚠️  解析日期失败 %s", dateStr), "date", dateStr, "error", err)
 			continue
 		}
 		holidays = append(holidays, model.Holiday{Date: date})
@@ -70,6 +70,9 @@ func runConversion[T any](
 		return outputFile, err
 	}
 
+	utils.Logger(ctx).Debug("转档完成", "suffix", suffix, "files", result.TotalItems,
+		"rows", result.OutputRows, "duration", result.Duration)
+
 	if result.HasErrors() {
 		return outputFile, fmt.Errorf("occurred %d errors, first: %v",
 			len(result.Errors), result.FirstError())
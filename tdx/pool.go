@@ -0,0 +1,99 @@
+package tdx
+
+import (
+	"context"
+	"fmt"
+)
+
+// ClientPool 是一组连到同一批候选主站的 OnlineClient，供并发请求借还。
+// 首个连接由调用方建立并测速，其余连接复用它的主站排名，不再重复测速。
+type ClientPool struct {
+	primary *OnlineClient
+	clones  []*OnlineClient
+	free    chan *OnlineClient
+}
+
+// NewClientPool 以已连接的 primary 为基础建立 size 个连接的池；Close 不会关闭 primary。
+func NewClientPool(primary *OnlineClient, size int) (*ClientPool, error) {
+	if size < 1 {
+		size = 1
+	}
+	p := &ClientPool{primary: primary, free: make(chan *OnlineClient, size)}
+	p.free <- primary
+	for i := 1; i < size; i++ {
+		c, err := primary.clone()
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("failed to open pooled TDX connection: %w", err)
+		}
+		p.clones = append(p.clones, c)
+		p.free <- c
+	}
+	return p, nil
+}
+
+// Size 返回池中连接数。
+func (p *ClientPool) Size() int {
+	return 1 + len(p.clones)
+}
+
+// Get 借出一个空闲连接，ctx 结束前一直等待。
+func (p *ClientPool) Get(ctx context.Context) (*OnlineClient, error) {
+	select {
+	case c := <-p.free:
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *ClientPool) Put(c *OnlineClient) {
+	p.free <- c
+}
+
+func (p *ClientPool) Close() error {
+	var first error
+	for _, c := range p.clones {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// clone 新建一个连到 c 当前主站 (失败时顺延) 的客户端，继承 ctx、超时与登录状态。
+func (c *OnlineClient) clone() (*OnlineClient, error) {
+	c.mu.Lock()
+	n := &OnlineClient{
+		Timeout:  c.Timeout,
+		ctx:      c.ctx,
+		standard: c.standard,
+		replay:   c.replay,
+		addrs:    append([]string(nil), c.addrs...),
+		current:  c.current,
+	}
+	loggedIn := c.loggedIn
+	c.mu.Unlock()
+
+	if len(n.addrs) == 0 {
+		return nil, fmt.Errorf("TDX online client is not connected")
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.dialFrom(n.current); err != nil {
+		return nil, err
+	}
+	if loggedIn {
+		if _, _, err := n.exchange(n.ctx, 0x0c, 0x0d, []byte{1}); err != nil {
+			n.conn.Close()
+			return nil, fmt.Errorf("failed to login TDX standard server: %w", err)
+		}
+		n.loggedIn = true
+	}
+	if !n.replay {
+		n.stopBeat = make(chan struct{})
+		go n.heartbeat(n.stopBeat)
+	}
+	return n, nil
+}
@@ -0,0 +1,68 @@
+package tdx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestFetchOnlineBlocksParallelIsDeterministic(t *testing.T) {
+	srv, err := NewFakeServer(scriptedResponder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	useHosts(t, srv.Addr())
+
+	old := BlockMemberWorkers
+	defer func() { BlockMemberWorkers = old }()
+
+	BlockMemberWorkers = 1
+	serialInfos, serialMembers, err := FetchOnlineBlocks(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	BlockMemberWorkers = 4
+	for i := 0; i < 3; i++ {
+		infos, members, err := FetchOnlineBlocks(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(infos, serialInfos) || !reflect.DeepEqual(members, serialMembers) {
+			t.Fatalf("parallel run %d differs from serial run", i)
+		}
+	}
+}
+
+func TestClientPoolReusesLogin(t *testing.T) {
+	logins := make(chan struct{}, 8)
+	srv, err := NewFakeServer(func(head byte, msgID uint16, body []byte) ([]byte, bool) {
+		if msgID == 0x0d {
+			logins <- struct{}{}
+		}
+		return []byte{0}, true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	useHosts(t, srv.Addr())
+
+	c := NewOnlineClient()
+	if err := c.ConnectStandard(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.LoginStandard(); err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := NewClientPool(c, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if pool.Size() != 3 || len(logins) != 3 {
+		t.Fatalf("pool size %d, logins %d", pool.Size(), len(logins))
+	}
+}
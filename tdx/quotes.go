@@ -0,0 +1,199 @@
+package tdx
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+// QuotesPageSize 是 0x53e 单次请求的最大代码数。
+const QuotesPageSize = 80
+
+// Quote 是 0x53e 返回的一只证券的实时行情，价格已换算为元，量单位为手。
+type Quote struct {
+	Symbol   string
+	Price    float64
+	PreClose float64
+	Open     float64
+	High     float64
+	Low      float64
+	Volume   int64
+	Amount   float64
+	Bid      [5]float64
+	Ask      [5]float64
+	BidVol   [5]int64
+	AskVol   [5]int64
+}
+
+// GetSecurityQuotes 取一批代码 (≤ 80) 的实时行情。需先 ConnectStandard + LoginStandard。
+func (c *OnlineClient) GetSecurityQuotes(symbols []string) ([]Quote, error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+	if len(symbols) > QuotesPageSize {
+		return nil, fmt.Errorf("too many symbols in one quotes request: %d > %d", len(symbols), QuotesPageSize)
+	}
+
+	// 0x53e: 0x0005(u16) + 0(u32) + 0(u16) + count(u16) + [market(u8) + code(6)] * count
+	body := make([]byte, 10+7*len(symbols))
+	binary.LittleEndian.PutUint16(body[0:2], 5)
+	binary.LittleEndian.PutUint16(body[8:10], uint16(len(symbols)))
+	for i, s := range symbols {
+		market, code, err := symbolMarket(s)
+		if err != nil {
+			return nil, err
+		}
+		off := 10 + i*7
+		body[off] = byte(market)
+		copy(body[off+1:off+7], code)
+	}
+
+	data, err := c.CallWithHead(0x0c, 0x53e, body)
+	if err != nil {
+		return nil, err
+	}
+	return parseSecurityQuotes(data)
+}
+
+func parseSecurityQuotes(data []byte) ([]Quote, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("security quotes response too short: %d", len(data))
+	}
+	n := int(binary.LittleEndian.Uint16(data[2:4]))
+	pos := 4
+	quotes := make([]Quote, 0, n)
+
+	// next 顺序读取一个变长整数，出错后后续读取都直接返回 0。
+	var perr error
+	next := func() int64 {
+		if perr != nil {
+			return 0
+		}
+		var v int64
+		v, pos, perr = readPrice(data, pos)
+		return v
+	}
+
+	for i := 0; i < n; i++ {
+		if pos+9 > len(data) {
+			return nil, fmt.Errorf("security quotes truncated at row %d", i)
+		}
+		market := uint16(data[pos])
+		code := string(data[pos+1 : pos+7])
+		pos += 9 // market + code + active(u16)
+
+		symbol, ok := marketCodeToSymbol(market, code)
+		if !ok {
+			return nil, fmt.Errorf("unknown market %d in quotes row %d", market, i)
+		}
+
+		price := next()
+		preDiff, openDiff, highDiff, lowDiff := next(), next(), next(), next()
+		next() // 服务器时间
+		next() // 保留
+		vol := next()
+		next() // 现量
+		if perr == nil && pos+4 > len(data) {
+			return nil, fmt.Errorf("security quotes truncated at row %d", i)
+		}
+		var amount float64
+		if perr == nil {
+			amount = decodeVolume(binary.LittleEndian.Uint32(data[pos : pos+4]))
+			pos += 4
+		}
+		next() // 内盘
+		next() // 外盘
+		next()
+		next()
+
+		var bid, ask [5]int64
+		q := Quote{Symbol: symbol, Volume: vol, Amount: amount}
+		for l := 0; l < 5; l++ {
+			bid[l], ask[l] = next(), next()
+			q.BidVol[l], q.AskVol[l] = next(), next()
+		}
+		if perr == nil && pos+2 > len(data) {
+			return nil, fmt.Errorf("security quotes truncated at row %d", i)
+		}
+		pos += 2
+		next()
+		next()
+		next()
+		next()
+		pos += 4
+		if perr != nil {
+			return nil, fmt.Errorf("security quotes row %d: %w", i, perr)
+		}
+
+		// 其余价格都是相对现价的差分；价格精度同 .day 文件 (股票 /100，ETF /1000)。
+		scale := model.PriceScale(symbol)
+		p := func(diff int64) float64 { return float64(price+diff) / scale }
+		q.Price, q.PreClose, q.Open, q.High, q.Low = p(0), p(preDiff), p(openDiff), p(highDiff), p(lowDiff)
+		for l := 0; l < 5; l++ {
+			q.Bid[l], q.Ask[l] = p(bid[l]), p(ask[l])
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, nil
+}
+
+// QuoteToSnapshot 转成 raw_quote_snapshot 行，量从手换算为股。
+func QuoteToSnapshot(q Quote, at time.Time) model.QuoteSnapshot {
+	s := model.QuoteSnapshot{
+		SnapshotTime: at,
+		Symbol:       q.Symbol,
+		Price:        q.Price,
+		PreClose:     q.PreClose,
+		Open:         q.Open,
+		High:         q.High,
+		Low:          q.Low,
+		Volume:       q.Volume * 100,
+		Amount:       q.Amount,
+	}
+	bids := []*float64{&s.Bid1, &s.Bid2, &s.Bid3, &s.Bid4, &s.Bid5}
+	asks := []*float64{&s.Ask1, &s.Ask2, &s.Ask3, &s.Ask4, &s.Ask5}
+	bidVols := []*int64{&s.BidVol1, &s.BidVol2, &s.BidVol3, &s.BidVol4, &s.BidVol5}
+	askVols := []*int64{&s.AskVol1, &s.AskVol2, &s.AskVol3, &s.AskVol4, &s.AskVol5}
+	for l := 0; l < 5; l++ {
+		*bids[l], *asks[l] = q.Bid[l], q.Ask[l]
+		*bidVols[l], *askVols[l] = q.BidVol[l]*100, q.AskVol[l]*100
+	}
+	return s
+}
+
+// FetchOnlineQuotes 按 QuotesPageSize 分批抓取实时行情，全部行共用同一个快照时间。
+func FetchOnlineQuotes(ctx context.Context, symbols []string) ([]model.QuoteSnapshot, error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+	client := NewOnlineClient().WithContext(ctx)
+	if err := client.ConnectStandard(); err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	if err := client.LoginStandard(); err != nil {
+		return nil, err
+	}
+
+	at := time.Now().Truncate(time.Second)
+	rows := make([]model.QuoteSnapshot, 0, len(symbols))
+	for start := 0; start < len(symbols); start += QuotesPageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(start+QuotesPageSize, len(symbols))
+		quotes, err := client.GetSecurityQuotes(symbols[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch quotes %d-%d: %w", start, end, err)
+		}
+		for _, q := range quotes {
+			rows = append(rows, QuoteToSnapshot(q, at))
+		}
+	}
+	utils.Logger(ctx).Debug("实时行情抓取完成", "symbols", len(symbols), "rows", len(rows))
+	return rows, nil
+}
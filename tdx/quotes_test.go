@@ -0,0 +1,73 @@
+package tdx
+
+import (
+	"testing"
+	"time"
+)
+
+func buildQuoteRow(market byte, code string, prices ...int64) []byte {
+	row := append([]byte{market}, code...)
+	row = append(row, 0, 0) // active
+	p := func(vs ...int64) {
+		for _, v := range vs {
+			row = append(row, encodePrice(v)...)
+		}
+	}
+	// price, preclose/open/high/low diff, servertime, reserved, vol, cur_vol
+	p(prices[:9]...)
+	row = append(row, 0, 0, 0, 0) // amount
+	p(0, 0, 0, 0)                 // s_vol, b_vol, reserved x2
+	p(prices[9:]...)              // 5 档 bid, ask, bid_vol, ask_vol
+	row = append(row, 0, 0)       // reserved
+	p(0, 0, 0, 0)
+	return append(row, 0, 0, 0, 0)
+}
+
+func TestParseSecurityQuotes(t *testing.T) {
+	levels := []int64{
+		-1, 1, 10, 20,
+		-2, 2, 11, 21,
+		-3, 3, 12, 22,
+		-4, 4, 13, 23,
+		-5, 5, 14, 24,
+	}
+	stock := buildQuoteRow(1, "600000", append([]int64{1050, -50, -20, 30, -40, 0, 0, 12345, 7}, levels...)...)
+	etf := buildQuoteRow(0, "159915", append([]int64{2345, 5, 0, 10, -10, 0, 0, 100, 1}, levels...)...)
+	data := append([]byte{0xb1, 0xcb, 2, 0}, stock...)
+	data = append(data, etf...)
+
+	quotes, err := parseSecurityQuotes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("got %d quotes", len(quotes))
+	}
+
+	q := quotes[0]
+	if q.Symbol != "sh600000" || q.Price != 10.5 || q.PreClose != 10 || q.Open != 10.3 ||
+		q.High != 10.8 || q.Low != 10.1 || q.Volume != 12345 {
+		t.Fatalf("unexpected stock quote %+v", q)
+	}
+	if q.Bid[0] != 10.49 || q.Ask[0] != 10.51 || q.BidVol[0] != 10 || q.AskVol[4] != 24 || q.Bid[4] != 10.45 {
+		t.Fatalf("unexpected stock levels %+v", q)
+	}
+
+	e := quotes[1]
+	if e.Symbol != "sz159915" || e.Price != 2.345 || e.PreClose != 2.35 || e.Ask[1] != 2.347 {
+		t.Fatalf("unexpected etf quote %+v", e)
+	}
+
+	if _, err := parseSecurityQuotes(data[:len(data)-10]); err == nil {
+		t.Fatal("expected error on truncated data")
+	}
+}
+
+func TestQuoteToSnapshot(t *testing.T) {
+	at := time.Date(2024, 6, 3, 10, 30, 0, 0, time.Local)
+	q := Quote{Symbol: "sh600000", Price: 10.5, Volume: 3, Bid: [5]float64{10.49}, BidVol: [5]int64{2, 0, 0, 0, 7}}
+	s := QuoteToSnapshot(q, at)
+	if !s.SnapshotTime.Equal(at) || s.Volume != 300 || s.Bid1 != 10.49 || s.BidVol1 != 200 || s.BidVol5 != 700 {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+}
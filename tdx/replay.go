@@ -0,0 +1,289 @@
+package tdx
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"sync"
+)
+
+// 录制 / 回放在线协议的环境变量。
+//
+//	TDX2DB_ONLINE_RECORD=frames.jsonl  CallWithHead 的每次请求 / 响应追加写入该文件
+//	TDX2DB_ONLINE_REPLAY=frames.jsonl  连接进程内假服务器，按录制的帧应答，不访问真实主站
+const (
+	EnvOnlineRecord = "TDX2DB_ONLINE_RECORD"
+	EnvOnlineReplay = "TDX2DB_ONLINE_REPLAY"
+)
+
+// Frame 是一次 CallWithHead 往返。Response 为解压后的响应体。
+type Frame struct {
+	Head     byte   `json:"head"`
+	MsgID    uint16 `json:"msg_id"`
+	Request  []byte `json:"request"`
+	Response []byte `json:"response"`
+}
+
+func (f Frame) key() string {
+	return frameKey(f.Head, f.MsgID, f.Request)
+}
+
+func frameKey(head byte, msgID uint16, body []byte) string {
+	return fmt.Sprintf("%02x:%04x:%x", head, msgID, body)
+}
+
+// Recorder 把帧以 JSON Lines 追加写入文件，可被多个 OnlineClient 并发使用。
+type Recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open online record file: %w", err)
+	}
+	return &Recorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (r *Recorder) Record(f Frame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(f)
+}
+
+// Close 把已写入的帧落盘后关闭文件。
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.f.Sync(); err != nil {
+		r.f.Close()
+		return fmt.Errorf("failed to sync online record file: %w", err)
+	}
+	return r.f.Close()
+}
+
+// LoadFrames 读取 Recorder 写出的帧文件。
+func LoadFrames(path string) ([]Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open online replay file: %w", err)
+	}
+	defer f.Close()
+
+	var frames []Frame
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var fr Frame
+		if err := dec.Decode(&fr); err != nil {
+			if errors.Is(err, io.EOF) {
+				return frames, nil
+			}
+			return nil, fmt.Errorf("failed to parse online replay file %s: %w", path, err)
+		}
+		frames = append(frames, fr)
+	}
+}
+
+// Responder 根据请求返回响应体；ok=false 时假服务器断开连接，客户端收到读错误。
+type Responder func(head byte, msgID uint16, body []byte) (resp []byte, ok bool)
+
+// FakeServer 是监听 127.0.0.1 的进程内通达信服务器，按 Responder 应答。
+type FakeServer struct {
+	ln      net.Listener
+	respond Responder
+	wg      sync.WaitGroup // serve 与各连接的 handle
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func NewFakeServer(respond Responder) (*FakeServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start fake TDX server: %w", err)
+	}
+	s := &FakeServer{ln: ln, respond: respond, conns: map[net.Conn]struct{}{}}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// NewReplayServer 用录制的帧应答。同一请求录到多次时按录制顺序依次返回，用尽后重复最后一次。
+func NewReplayServer(frames []Frame) (*FakeServer, error) {
+	var mu sync.Mutex
+	byKey := make(map[string][]Frame)
+	for _, f := range frames {
+		byKey[f.key()] = append(byKey[f.key()], f)
+	}
+	return NewFakeServer(func(head byte, msgID uint16, body []byte) ([]byte, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		k := frameKey(head, msgID, body)
+		queue := byKey[k]
+		if len(queue) == 0 {
+			slog.Warn("⚠️ 回放文件中没有匹配的请求", "msg_id", fmt.Sprintf("0x%x", msgID), "body", fmt.Sprintf("%x", body))
+			return nil, false
+		}
+		if len(queue) > 1 {
+			byKey[k] = queue[1:]
+		}
+		return queue[0].Response, true
+	})
+}
+
+// Addr 返回 host:port。
+func (s *FakeServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close 停止监听并断开所有客户端连接，等待连接处理协程退出后返回。
+func (s *FakeServer) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	err := s.ln.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *FakeServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// handle 按 CallWithHead 的帧格式读请求：head(1) + customize(4) + 1(1) + len(2) + len(2) + msgID(2) + body。
+// 响应体不压缩，zippedSize == unzipSize。
+func (s *FakeServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	header := make([]byte, 10)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		payload := make([]byte, binary.LittleEndian.Uint16(header[6:8]))
+		if _, err := io.ReadFull(conn, payload); err != nil || len(payload) < 2 {
+			return
+		}
+		msgID := binary.LittleEndian.Uint16(payload[0:2])
+		resp, ok := s.respond(header[0], msgID, payload[2:])
+		if !ok {
+			return
+		}
+		// 长度头只有 16 位，超长响应写不下，断开连接让客户端报错而不是读到错位的数据流。
+		if len(resp) > math.MaxUint16 {
+			slog.Warn("⚠️ 回放响应超过 64 KiB，断开连接", "msg_id", fmt.Sprintf("0x%x", msgID), "size", len(resp))
+			return
+		}
+
+		out := make([]byte, responseHeaderLen+len(resp))
+		out[0] = header[0]
+		binary.LittleEndian.PutUint16(out[10:12], msgID)
+		binary.LittleEndian.PutUint16(out[12:14], uint16(len(resp)))
+		binary.LittleEndian.PutUint16(out[14:16], uint16(len(resp)))
+		copy(out[responseHeaderLen:], resp)
+		if _, err := conn.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+// 录制器与回放服务器按文件路径在进程内复用。
+var (
+	onlineHooksMu sync.Mutex
+	recorders     = map[string]*Recorder{}
+	replayServers = map[string]*FakeServer{}
+)
+
+// activeRecorder 返回 TDX2DB_ONLINE_RECORD 对应的录制器，未设置时为 nil。
+// 打开失败时不缓存，下次调用重试，由调用方把错误透出。
+func activeRecorder() (*Recorder, error) {
+	path := os.Getenv(EnvOnlineRecord)
+	if path == "" {
+		return nil, nil
+	}
+	onlineHooksMu.Lock()
+	defer onlineHooksMu.Unlock()
+	if r, ok := recorders[path]; ok {
+		return r, nil
+	}
+	r, err := NewRecorder(path)
+	if err != nil {
+		return nil, err
+	}
+	recorders[path] = r
+	return r, nil
+}
+
+// CloseOnlineHooks 关闭进程内打开的录制文件与回放服务器，供 cron / quotes 退出前调用。
+func CloseOnlineHooks() error {
+	onlineHooksMu.Lock()
+	defer onlineHooksMu.Unlock()
+	var errs []error
+	for path, r := range recorders {
+		errs = append(errs, r.Close())
+		delete(recorders, path)
+	}
+	for path, s := range replayServers {
+		errs = append(errs, s.Close())
+		delete(replayServers, path)
+	}
+	return errors.Join(errs...)
+}
+
+// replayAddr 返回 TDX2DB_ONLINE_REPLAY 对应假服务器的地址；未设置时返回空串。
+func replayAddr() (string, error) {
+	path := os.Getenv(EnvOnlineReplay)
+	if path == "" {
+		return "", nil
+	}
+	onlineHooksMu.Lock()
+	defer onlineHooksMu.Unlock()
+	if s, ok := replayServers[path]; ok {
+		return s.Addr(), nil
+	}
+	frames, err := LoadFrames(path)
+	if err != nil {
+		return "", err
+	}
+	s, err := NewReplayServer(frames)
+	if err != nil {
+		return "", err
+	}
+	replayServers[path] = s
+	slog.Debug("在线协议回放服务器已启动", "path", path, "frames", len(frames), "addr", s.Addr())
+	return s.Addr(), nil
+}
@@ -0,0 +1,221 @@
+package tdx
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// scriptedResponder 按消息号合成最小可用的响应：每个市场两只证券、每类一个板块、每个板块一只成分股。
+func scriptedResponder(head byte, msgID uint16, body []byte) ([]byte, bool) {
+	switch msgID {
+	case 0x0d: // login
+		return []byte{0}, true
+	case 0x44d: // stock list
+		market := binary.LittleEndian.Uint16(body[0:2])
+		codes := map[uint16][]string{0: {"000001", "159915"}, 1: {"600000", "000300"}, 2: {"830799", "899050"}}[market]
+		resp := make([]byte, 2+37*len(codes))
+		binary.LittleEndian.PutUint16(resp[0:2], uint16(len(codes)))
+		for i, code := range codes {
+			off := 2 + i*37
+			copy(resp[off:off+6], code)
+			copy(resp[off+8:off+24], "N"+code)
+		}
+		return resp, true
+	case 0x1231: // block list
+		blockType := binary.LittleEndian.Uint16(body[2:4])
+		resp := make([]byte, 4+160)
+		binary.LittleEndian.PutUint16(resp[0:2], 2)
+		binary.LittleEndian.PutUint16(resp[4:6], 1)
+		copy(resp[6:12], []byte{'8', '8', '0', '5', '0', '0' + byte(blockType)})
+		copy(resp[28:72], "B")
+		return resp, true
+	case 0x122c: // block members
+		resp := make([]byte, 26+68)
+		binary.LittleEndian.PutUint16(resp[24:26], 1)
+		binary.LittleEndian.PutUint16(resp[26:28], 1)
+		copy(resp[28:50], "600000")
+		return resp, true
+	}
+	return nil, false
+}
+
+func useHosts(t *testing.T, addr string) {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldMac, oldStd := macHosts, standardHosts
+	hosts := []Host{{"fake", host, port}}
+	macHosts, standardHosts = hosts, hosts
+	t.Cleanup(func() { macHosts, standardHosts = oldMac, oldStd })
+}
+
+func TestRecordAndReplayOnlineFetch(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "frames.jsonl")
+
+	srv, err := NewFakeServer(scriptedResponder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useHosts(t, srv.Addr())
+
+	t.Setenv(EnvOnlineRecord, path)
+	t.Cleanup(func() { CloseOnlineHooks() })
+	names, err := FetchOnlineSymbolNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, members, err := FetchOnlineBlocks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+	t.Setenv(EnvOnlineRecord, "")
+
+	if len(names) != 6 || len(infos) != 6 || len(members) != 6 {
+		t.Fatalf("unexpected live results: %d names, %d blocks, %d members", len(names), len(infos), len(members))
+	}
+
+	frames, err := LoadFrames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// login + 3 个市场 + 6 类板块列表 + 6 个板块成分
+	if len(frames) != 16 {
+		t.Fatalf("recorded %d frames, want 16", len(frames))
+	}
+
+	// 真实主站已不可达，回放必须完全不依赖它。
+	useHosts(t, "127.0.0.1:1")
+	t.Setenv(EnvOnlineReplay, path)
+
+	replayNames, err := FetchOnlineSymbolNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayInfos, replayMembers, err := FetchOnlineBlocks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, replayNames) || !reflect.DeepEqual(infos, replayInfos) || !reflect.DeepEqual(members, replayMembers) {
+		t.Fatal("replayed results differ from recorded run")
+	}
+}
+
+func TestActiveRecorderOpenError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "missing", "frames.jsonl")
+	t.Setenv(EnvOnlineRecord, path)
+	t.Cleanup(func() { CloseOnlineHooks() })
+
+	if r, err := activeRecorder(); err == nil || r != nil {
+		t.Fatalf("expected open error, got recorder %v, err %v", r, err)
+	}
+	// 打开失败不缓存：目录补上后下一次调用即可录制。
+	if err := os.Mkdir(filepath.Join(dir, "missing"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	r, err := activeRecorder()
+	if err != nil || r == nil {
+		t.Fatalf("expected recorder after retry, got %v, err %v", r, err)
+	}
+	if err := r.Record(Frame{MsgID: 0x0d, Request: []byte{1}, Response: []byte{0}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CloseOnlineHooks(); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := LoadFrames(path)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("expected 1 frame after close, got %d, err %v", len(frames), err)
+	}
+}
+
+func TestReplayServerUnknownRequest(t *testing.T) {
+	srv, err := NewReplayServer([]Frame{{Head: 0x0c, MsgID: 0x0d, Request: []byte{1}, Response: []byte{0}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	useHosts(t, srv.Addr())
+
+	c := NewOnlineClient()
+	if err := c.ConnectStandard(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.LoginStandard(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CallWithHead(0x0c, 0x44d, []byte{9}); err == nil {
+		t.Fatal("expected error for request missing from replay file")
+	}
+}
+
+func TestFakeServerDropsOversizedResponse(t *testing.T) {
+	srv, err := NewFakeServer(func(head byte, msgID uint16, body []byte) ([]byte, bool) {
+		if msgID == 0x0d {
+			return []byte{0}, true
+		}
+		return make([]byte, math.MaxUint16+1), true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	useHosts(t, srv.Addr())
+
+	c := NewOnlineClient()
+	if err := c.ConnectStandard(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.LoginStandard(); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := c.CallWithHead(0x0c, 0x44d, []byte{0, 0}); err == nil {
+		t.Fatalf("expected error for oversized response, got %d bytes", len(resp))
+	}
+}
+
+// Close 要断开仍连着的客户端，并等到连接处理协程退出。
+func TestFakeServerCloseDropsConnections(t *testing.T) {
+	srv, err := NewFakeServer(scriptedResponder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return while a client was connected")
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected client connection closed by server, got %v", err)
+	}
+}
@@ -16,7 +16,7 @@ type stockListItem struct {
 }
 
 func FetchOnlineSymbolNames(ctx context.Context) ([]model.SymbolName, error) {
-	client := NewOnlineClient()
+	client := NewOnlineClient().WithContext(ctx)
 	if err := client.ConnectStandard(); err != nil {
 		return nil, err
 	}
@@ -62,7 +62,7 @@ func FetchOnlineSymbolNames(ctx context.Context) ([]model.SymbolName, error) {
 
 func isOnlineSymbolNameClass(class string) bool {
 	switch class {
-	case model.ClassStock, model.ClassBStock, model.ClassETF, model.ClassIndex:
+	case model.ClassStock, model.ClassBStock, model.ClassETF, model.ClassIndex, model.ClassCBond:
 		return true
 	}
 	return false
@@ -73,6 +73,9 @@ func (c *OnlineClient) LoginStandard() error {
 	if err != nil {
 		return fmt.Errorf("failed to login TDX standard server: %w", err)
 	}
+	c.mu.Lock()
+	c.loggedIn = true
+	c.mu.Unlock()
 	return nil
 }
 
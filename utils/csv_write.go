@@ -147,7 +147,14 @@ func (cw *CSVWriter[T]) Write(data []T) error {
 			}
 			// ------------------
 
-			// 其他类型通用处理
+			// 其他类型通用处理；nil 指针留空 (NULL)
+			if fieldVal.Kind() == reflect.Ptr {
+				if fieldVal.IsNil() {
+					record[i] = ""
+					continue
+				}
+				fieldVal = fieldVal.Elem()
+			}
 			record[i] = fmt.Sprint(fieldVal.Interface())
 		}
 
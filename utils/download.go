@@ -9,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,6 +21,14 @@ const (
 	defaultUserAgent    = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
 )
 
+// downloadedBytes 累计本进程写盘的下载字节数, 供 metrics 导出。
+var downloadedBytes atomic.Int64
+
+// DownloadedBytes 返回本进程累计下载字节数。
+func DownloadedBytes() int64 {
+	return downloadedBytes.Load()
+}
+
 // DownloadOptions 控制单次下载的请求头 / 重试 / 超时。零值字段走默认:
 // MaxAttempts<=0 → 3, RetryBackoff<=0 → 3s, Timeout==0 → 不限 (沿用大文件慢下载),
 // Headers 始终在默认 UA + 自动 Referer 之上叠加 (同名覆盖)。
@@ -93,6 +102,31 @@ func DownloadFileWithOptions(ctx context.Context, downloadURL, targetPath string
 	return lastStatus, fmt.Errorf("download %s failed after %d attempts: %w", downloadURL, maxAttempts, lastErr)
 }
 
+// ProbeURL 对 url 发一次 HEAD 请求并返回状态码, 不落盘。
+// 请求头与 DownloadFileWithOptions 一致 (浏览器 UA + 自动 Referer), 避免探测结果
+// 与真实下载不一致; Timeout==0 时按 10s 兜底, 防止单个 URL 卡住整轮探测。
+func ProbeURL(ctx context.Context, probeURL string, opt DownloadOptions) (int, error) {
+	timeout := opt.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	d := &Download{
+		Url:     probeURL,
+		client:  &http.Client{Timeout: timeout},
+		headers: buildHeaders(probeURL, opt.Headers),
+	}
+	r, err := d.newRequest(ctx, "HEAD")
+	if err != nil {
+		return 0, err
+	}
+	res, err := d.client.Do(r)
+	if err != nil {
+		return 0, fmt.Errorf("execute HEAD request: %w", err)
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
 // buildHeaders 在默认 UA + 自动 Referer (scheme://host/) 之上叠加调用方 headers (同名覆盖)。
 func buildHeaders(downloadURL string, override map[string]string) map[string]string {
 	h := map[string]string{"User-Agent": defaultUserAgent}
@@ -227,7 +261,9 @@ func (d *Download) downloadSection(ctx context.Context, i int, section [2]int) e
 	}
 	defer f.Close()
 
-	if _, err := io.Copy(f, resp.Body); err != nil {
+	n, err := io.Copy(f, resp.Body)
+	downloadedBytes.Add(n)
+	if err != nil {
 		return fmt.Errorf("write part %d: %w", i, err)
 	}
 
@@ -280,7 +316,9 @@ func (d *Download) singleThreadDownload(ctx context.Context) (int, error) {
 	}
 	defer f.Close()
 
-	if _, err := io.Copy(f, resp.Body); err != nil {
+	n, err := io.Copy(f, resp.Body)
+	downloadedBytes.Add(n)
+	if err != nil {
 		return resp.StatusCode, fmt.Errorf("write target: %w", err)
 	}
 
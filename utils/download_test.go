@@ -87,3 +87,31 @@ func TestDownloadFileAutoReferer(t *testing.T) {
 		t.Fatalf("auto Referer = %q, want %q", gotRef, srv.URL+"/")
 	}
 }
+
+// TestProbeURLHeadOnly 验证探测只发 HEAD、透传 404 状态且不产生文件。
+func TestProbeURLHeadOnly(t *testing.T) {
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.URL.Path == "/missing.zip" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	status, err := ProbeURL(context.Background(), srv.URL+"/ok.zip", DownloadOptions{})
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("probe ok: status=%d err=%v", status, err)
+	}
+	status, err = ProbeURL(context.Background(), srv.URL+"/missing.zip", DownloadOptions{})
+	if err != nil || status != http.StatusNotFound {
+		t.Fatalf("probe missing: status=%d err=%v", status, err)
+	}
+	for _, m := range methods {
+		if m != http.MethodHead {
+			t.Fatalf("expected only HEAD requests, got %v", methods)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 日志格式: text 保留原先带 emoji 的中文提示, 方便人读;
+// json 每行一条 slog JSON 记录, 交给日志采集器解析 task / symbol / rows 等字段。
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// NewLogger 按 level (debug/info/warn/error) 与 format (text/json) 构造 logger。
+// text 格式 Info 及以下写 stdout, Warn 及以上写 stderr, 与改造前的输出位置一致;
+// json 格式全部写 stdout。
+func NewLogger(level, format string) (*slog.Logger, error) {
+	var lv slog.Level
+	if err := lv.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q (debug|info|warn|error)", level)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", LogFormatText:
+		return slog.New(&humanHandler{level: lv, out: os.Stdout, errOut: os.Stderr, mu: &sync.Mutex{}}), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: lv})), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q (text|json)", format)
+	}
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger 把 logger 挂到 ctx 上, 供 tdx / calc 等只拿得到 ctx 的包使用。
+func ContextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// Logger 取 ctx 上的 logger, 没有时退回 slog.Default()。
+func Logger(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && l != nil {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// humanHandler 只输出消息正文, 结构化字段留给 json 格式;
+// 唯一例外是 error 字段, 以 ": <err>" 追加在消息后, 保持原先的报错样式。
+type humanHandler struct {
+	level  slog.Level
+	out    io.Writer
+	errOut io.Writer
+	mu     *sync.Mutex
+	attrs  []slog.Attr
+}
+
+func (h *humanHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level
+}
+
+func (h *humanHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	sb.WriteString(r.Message)
+
+	var errVal string
+	find := func(a slog.Attr) bool {
+		if a.Key == "error" {
+			errVal = a.Value.String()
+			return false
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		if !find(a) {
+			break
+		}
+	}
+	r.Attrs(find)
+	if errVal != "" {
+		sb.WriteString(": ")
+		sb.WriteString(errVal)
+	}
+	sb.WriteByte('\n')
+
+	w := h.out
+	if r.Level >= slog.LevelWarn {
+		w = h.errOut
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (h *humanHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	nh.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &nh
+}
+
+// WithGroup 对人读格式无意义, 原样返回。
+func (h *humanHandler) WithGroup(string) slog.Handler {
+	return h
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// TestHumanHandlerKeepsMessageStyle 验证人读格式只输出消息, error 追加在末尾,
+// Warn 及以上写到 errOut。
+func TestHumanHandlerKeepsMessageStyle(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := slog.New(&humanHandler{level: slog.LevelInfo, out: &out, errOut: &errOut, mu: &sync.Mutex{}})
+
+	l.With("task", "update_daily").Info("🌲 日线数据无需更新", "rows", 0)
+	l.Debug("hidden")
+	l.Warn("💢 任务失败", "error", errors.New("boom"))
+
+	if got := out.String(); got != "🌲 日线数据无需更新\n" {
+		t.Fatalf("stdout = %q", got)
+	}
+	if got := errOut.String(); got != "💢 任务失败: boom\n" {
+		t.Fatalf("stderr = %q", got)
+	}
+}
+
+func TestNewLoggerRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewLogger("info", "xml"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+	if _, err := NewLogger("verbose", "text"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
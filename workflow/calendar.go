@@ -1,17 +1,32 @@
 package workflow
 
-import "time"
+import (
+	"time"
 
+	"github.com/jing2uo/tdx2db/model"
+)
+
+// CalendarStart 是交易日历的起点 (上交所开业日)。
+var CalendarStart = time.Date(1990, 12, 19, 0, 0, 0, 0, time.UTC)
+
+// TradingCalendar 是基于 raw_holidays + 周末推算的交易日历。
+// 超出节假日数据覆盖范围的日期只按周末判断。节假日集合有限，
+// 向前 / 向后查找总能在有限步内遇到交易日，因此各方法不设步数上限。
 type TradingCalendar struct {
 	holidays map[string]struct{}
+	latest   time.Time
 }
 
 func NewTradingCalendar(holidays []time.Time) *TradingCalendar {
 	set := make(map[string]struct{}, len(holidays))
+	var latest time.Time
 	for _, d := range holidays {
 		set[d.Format("2006-01-02")] = struct{}{}
+		if d.After(latest) {
+			latest = d
+		}
 	}
-	return &TradingCalendar{holidays: set}
+	return &TradingCalendar{holidays: set, latest: latest}
 }
 
 func (c *TradingCalendar) IsHoliday(d time.Time) bool {
@@ -31,11 +46,97 @@ func (c *TradingCalendar) IsTradingDay(d time.Time) bool {
 // LastTradingDayOnOrBefore 返回 ≤ d 的最近交易日。
 func (c *TradingCalendar) LastTradingDayOnOrBefore(d time.Time) time.Time {
 	cur := d
-	for i := 0; i < 30; i++ {
-		if c.IsTradingDay(cur) {
-			return cur
-		}
+	for !c.IsTradingDay(cur) {
 		cur = cur.AddDate(0, 0, -1)
 	}
 	return cur
 }
+
+// Next 返回严格晚于 d 的下一个交易日。
+func (c *TradingCalendar) Next(d time.Time) time.Time {
+	return c.step(d, 1)
+}
+
+// Prev 返回严格早于 d 的上一个交易日。
+func (c *TradingCalendar) Prev(d time.Time) time.Time {
+	return c.step(d, -1)
+}
+
+func (c *TradingCalendar) step(d time.Time, dir int) time.Time {
+	cur := d.AddDate(0, 0, dir)
+	for !c.IsTradingDay(cur) {
+		cur = cur.AddDate(0, 0, dir)
+	}
+	return cur
+}
+
+// AddTradingDays 返回 d 之后第 n 个交易日，n 为负时向前数；n 为 0 返回 d 本身。
+func (c *TradingCalendar) AddTradingDays(d time.Time, n int) time.Time {
+	cur := d
+	for ; n > 0; n-- {
+		cur = c.Next(cur)
+	}
+	for ; n < 0; n++ {
+		cur = c.Prev(cur)
+	}
+	return cur
+}
+
+// NextTradingDays 返回 d 之后 (不含 d) 的 n 个交易日，升序。
+func (c *TradingCalendar) NextTradingDays(d time.Time, n int) []time.Time {
+	days := make([]time.Time, 0, max(n, 0))
+	cur := d
+	for i := 0; i < n; i++ {
+		cur = c.Next(cur)
+		days = append(days, cur)
+	}
+	return days
+}
+
+// TradingDays 返回 [start, end] 内的全部交易日，升序。
+func (c *TradingCalendar) TradingDays(start, end time.Time) []time.Time {
+	var days []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if c.IsTradingDay(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// CalendarEnd 返回物化日历的终点：节假日数据与 today 中较晚一年的年末。
+func (c *TradingCalendar) CalendarEnd(today time.Time) time.Time {
+	year := today.Year()
+	if c.latest.Year() > year {
+		year = c.latest.Year()
+	}
+	return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+}
+
+// Rows 展开 [start, end] 内每个自然日的日历记录。TradeSeq 从 start 起的第一个交易日记为 1，
+// 非交易日沿用之前最近交易日的序号 (start 之前没有交易日时为 0)，两日相减即相隔的交易日数；
+// 上 / 下一交易日超出 start / end 时仍按日历推算，但早于 CalendarStart 的上一交易日为 NULL。
+func (c *TradingCalendar) Rows(start, end time.Time) []model.TradeCalendar {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+
+	var rows []model.TradeCalendar
+	seq := 0
+	var prev *time.Time
+	if p := c.Prev(start); !p.Before(CalendarStart) {
+		prev = &p
+	}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		next := c.Next(d)
+		row := model.TradeCalendar{Date: d, PrevTradingDay: prev, NextTradingDay: next}
+		if c.IsTradingDay(d) {
+			seq++
+			row.IsTradingDay = 1
+			day := d
+			prev = &day
+		}
+		row.TradeSeq = seq
+		rows = append(rows, row)
+	}
+	return rows
+}
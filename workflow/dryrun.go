@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+// DateProbe 是 dry-run 对单个日期 zip 的 HEAD 探测结果。
+type DateProbe struct {
+	Label  string // "日线" / "分时"
+	Date   time.Time
+	URL    string
+	Status int   // HTTP 状态码；请求失败时为 0
+	Err    error // 网络错误等
+	Note   string
+}
+
+// Published 远端是否已发布该日 zip。
+func (p DateProbe) Published() bool {
+	return p.Status == 200
+}
+
+// ProbeRemote 对 pullDateRange 将要下载的每个 g4day / g4tic URL 发 HEAD 请求，
+// 只读数据库、不写临时目录，用于排查"数据尚未发布"。
+// 分时仅在 args.Min 为 true 时探测，与 cron 实际行为一致。
+func ProbeRemote(ctx context.Context, db database.DataRepository, args *TaskArgs) ([]DateProbe, error) {
+	var probes []DateProbe
+
+	dailyLatest, err := db.GetLatestDate(model.TableKlineDaily.TableName, "date")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest daily date: %w", err)
+	}
+	if !dailyLatest.IsZero() {
+		ps, err := probeDateRange(ctx, dailySince(dailyLatest, args.Plan), dailySource(args), args)
+		if err != nil {
+			return probes, err
+		}
+		probes = append(probes, ps...)
+	}
+
+	if args.Min {
+		minLatest, err := db.GetLatestDate(model.TableKline1Min.TableName, "datetime")
+		if err != nil {
+			return probes, fmt.Errorf("query 1min latest: %w", err)
+		}
+		if minLatest.IsZero() {
+			minLatest = args.Today.AddDate(0, 0, -1)
+		}
+		ps, err := probeDateRange(ctx, minLatest, ticSource(args), args)
+		if err != nil {
+			return probes, err
+		}
+		probes = append(probes, ps...)
+	}
+
+	return probes, nil
+}
+
+func probeDateRange(ctx context.Context, since time.Time, src pullSource, args *TaskArgs) ([]DateProbe, error) {
+	dates := pendingDates(since, args.Today)
+	probes := make([]DateProbe, 0, len(dates))
+	for _, date := range dates {
+		if err := ctx.Err(); err != nil {
+			return probes, err
+		}
+		url := fmt.Sprintf(src.urlTemplate, date.Format("20060102"))
+		status, err := utils.ProbeURL(ctx, url, utils.DownloadOptions{})
+		p := DateProbe{Label: src.label, Date: date, URL: url, Status: status, Err: err}
+		switch {
+		case err != nil:
+			p.Note = fmt.Sprintf("❌ %s 探测失败: %v", date.Format("20060102"), err)
+		case status == 200:
+			p.Note = fmt.Sprintf("✅ %s 已发布", date.Format("20060102"))
+		case status == 404:
+			p.Note = missingDateNote(date, args)
+		default:
+			p.Note = fmt.Sprintf("⚠️ %s 返回异常状态 %d", date.Format("20060102"), status)
+		}
+		probes = append(probes, p)
+	}
+	return probes, nil
+}
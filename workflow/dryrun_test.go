@@ -0,0 +1,47 @@
+package workflow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// dry-run 探测的 g4day 日期要与 update_daily 实际下载的一致：有在线补齐日期时从最早补齐日期起。
+func TestProbeRemoteIncludesPatchedDates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	old := tdxDataURL
+	tdxDataURL = srv.URL
+	t.Cleanup(func() { tdxDataURL = old })
+
+	db := openTestDB(t)
+	importDaily(t, db, "2024-01-02", "2024-01-03", "2024-01-04")
+	patched := markPatched(t, db)
+
+	probed := func(plan *WorkPlan) []time.Time {
+		t.Helper()
+		probes, err := ProbeRemote(context.Background(), db, &TaskArgs{Today: day("2024-01-05"), Plan: plan})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dates []time.Time
+		for _, p := range probes {
+			if !p.Published() {
+				t.Fatalf("unexpected probe: %+v", p)
+			}
+			dates = append(dates, p.Date)
+		}
+		return dates
+	}
+
+	want := []time.Time{day("2024-01-03"), day("2024-01-04"), day("2024-01-05")}
+	if got := probed(&WorkPlan{PatchedDaily: patched}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("with patched dates: got %v, want %v", got, want)
+	}
+	if got := probed(&WorkPlan{}); !reflect.DeepEqual(got, want[2:]) {
+		t.Fatalf("without patched dates: got %v, want %v", got, want[2:])
+	}
+}
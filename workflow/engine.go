@@ -3,10 +3,12 @@ package workflow
 import (
 	"context"
 	"fmt"
-	"os"
+	"log/slog"
 	"time"
 
 	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
 )
 
 // TaskState represents the state of a task execution
@@ -22,10 +24,11 @@ const (
 
 // TaskResult holds the execution result of a task
 type TaskResult struct {
-	State   TaskState
-	Rows    int
-	Message string
-	Error   error
+	State    TaskState
+	Rows     int
+	Message  string
+	Error    error
+	Duration time.Duration
 }
 
 type ErrorMode int
@@ -47,23 +50,54 @@ type Task struct {
 	DependsOn []string
 	Executor  TaskFunc
 	SkipIf    SkipCondition
-	OnError   ErrorMode
+	// SkipReason 描述 SkipIf 命中时的原因，供 dry-run 展示。
+	SkipReason string
+	OnError    ErrorMode
 }
 
 type TaskArgs struct {
 	Min        bool
+	OnlineFill bool // 用在线行情补齐 g4day 缺失 / 未发布的交易日
+	Quotes     bool // 运行末尾抓取一次实时行情快照
+	// Benchmark 是 calc_returns 计算超额收益的基准指数代码，留空时超额收益为 NULL。
+	Benchmark  string
 	TempDir    string
 	VipdocDir  string
 	DayFileDir string
 	Today      time.Time
 	Plan       *WorkPlan
-	Extra      map[string]interface{}
+	// OnlineSymbols 由 update_symbol_names 写入本轮抓到的在线代码表，抓取失败时为 nil。
+	OnlineSymbols []model.SymbolName
+	// ImportedDaily 由 update_daily / fill_daily_online 写入本轮导入 raw_kline_daily 的日期，
+	// 其中早于衍生表最新日期的 (官方数据覆盖在线补齐等) 需要重算。
+	ImportedDaily []time.Time
+	Extra         map[string]interface{}
+	// Logger 为空时退回 slog.Default()。执行器会给每个任务派生带 task 字段的
+	// 子 logger 挂到 ctx 上，任务及其调用的 tdx / calc 通过 utils.Logger(ctx) 取用。
+	Logger *slog.Logger
+}
+
+func (a *TaskArgs) logger() *slog.Logger {
+	if a.Logger != nil {
+		return a.Logger
+	}
+	return slog.Default()
 }
 
 // TaskExecutor manages and executes tasks with dependency resolution
 type TaskExecutor struct {
 	db    database.DataRepository
 	tasks map[string]*Task
+
+	// 最近一次 Run 的执行顺序与结果，供 metrics / 通知汇总。
+	lastOrder   []string
+	lastResults map[string]*TaskResult
+}
+
+// TaskReport 是单个任务在最近一次 Run 中的结果。
+type TaskReport struct {
+	Name string
+	TaskResult
 }
 
 // NewTaskExecutor creates a new task executor
@@ -98,6 +132,8 @@ func (te *TaskExecutor) Run(ctx context.Context, taskNames []string, args *TaskA
 	}
 
 	results := make(map[string]*TaskResult)
+	te.lastOrder = order
+	te.lastResults = results
 	pending := make(map[string]bool)
 	for _, name := range order {
 		pending[name] = true
@@ -170,8 +206,8 @@ func (te *TaskExecutor) Run(ctx context.Context, taskNames []string, args *TaskA
 				// ErrorModeSkip: 不阻塞流程, 但必须把 error 透出来,
 				// 否则失败的任务在日志里完全无痕, 整体 return nil
 				// 用户看到"今日任务执行成功"很容易误判。
-				fmt.Fprintf(os.Stderr, "💢 任务 %s 失败但已跳过: %v\n",
-					completed.name, completed.result.Error)
+				args.logger().Warn(fmt.Sprintf("💢 任务 %s 失败但已跳过", completed.name),
+					"task", completed.name, "error", completed.result.Error)
 			}
 		}
 	}
@@ -180,13 +216,24 @@ func (te *TaskExecutor) Run(ctx context.Context, taskNames []string, args *TaskA
 }
 
 func (te *TaskExecutor) executeTask(ctx context.Context, task *Task, args *TaskArgs) *TaskResult {
+	log := args.logger().With("task", task.Name)
+	ctx = utils.ContextWithLogger(ctx, log)
+
+	start := time.Now()
 	result, err := task.Executor(ctx, te.db, args)
 	if err != nil {
-		return &TaskResult{
+		result = &TaskResult{
 			State: StateFailed,
 			Error: err,
 		}
 	}
+	result.Duration = time.Since(start)
+
+	log.Info(fmt.Sprintf("⏱️  任务 %s %s，耗时 %s", task.Name, result.State, result.Duration.Round(time.Millisecond)),
+		"state", string(result.State),
+		"rows", result.Rows,
+		"duration", result.Duration,
+	)
 	return result
 }
 
@@ -214,9 +261,10 @@ func (te *TaskExecutor) topologicalSort(taskNames []string) ([]string, error) {
 		}
 	}
 
+	// 按 taskNames 原顺序入队，保证同一批任务的拓扑序稳定（dry-run 输出依赖这一点）。
 	var queue []string
-	for name, degree := range inDegree {
-		if degree == 0 {
+	for _, name := range taskNames {
+		if inDegree[name] == 0 {
 			queue = append(queue, name)
 		}
 	}
@@ -254,7 +302,7 @@ func (te *TaskExecutor) findReadyTasks(pending map[string]bool, results map[stri
 				continue
 			}
 			result, exists := results[dep]
-			if !exists || (result.State != StateCompleted && result.State != StateSkipped) {
+			if !exists || !te.depSatisfied(dep, result) {
 				allDepsDone = false
 				break
 			}
@@ -268,6 +316,65 @@ func (te *TaskExecutor) findReadyTasks(pending map[string]bool, results map[stri
 	return ready
 }
 
+// depSatisfied 判断依赖是否已放行下游：完成 / 跳过均可；
+// ErrorModeSkip 的任务失败同样放行，否则下游永远等不到就绪。
+func (te *TaskExecutor) depSatisfied(dep string, result *TaskResult) bool {
+	switch result.State {
+	case StateCompleted, StateSkipped:
+		return true
+	case StateFailed:
+		t, ok := te.tasks[dep]
+		return ok && t.OnError == ErrorModeSkip
+	}
+	return false
+}
+
+// TaskPreview 是 dry-run 下单个任务的预判结果。
+type TaskPreview struct {
+	Name      string
+	DependsOn []string
+	WillRun   bool
+	Reason    string
+}
+
+// Preview 按执行顺序评估每个任务的 SkipIf，但不调用 Executor。
+// 运行期才能确定的跳过（如"无新数据"）不在这里体现。
+func (te *TaskExecutor) Preview(ctx context.Context, taskNames []string, args *TaskArgs) ([]TaskPreview, error) {
+	order, err := te.topologicalSort(taskNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve task dependencies: %w", err)
+	}
+
+	previews := make([]TaskPreview, 0, len(order))
+	for _, name := range order {
+		task := te.tasks[name]
+		p := TaskPreview{Name: name, DependsOn: task.DependsOn, WillRun: true, Reason: "将执行"}
+		if task.SkipIf != nil && task.SkipIf(ctx, te.db, args) {
+			p.WillRun = false
+			p.Reason = task.SkipReason
+			if p.Reason == "" {
+				p.Reason = "skipped by condition"
+			}
+		}
+		previews = append(previews, p)
+	}
+	return previews, nil
+}
+
+// Report 按执行顺序返回最近一次 Run 的各任务结果；
+// 因前序失败或中断而未执行的任务状态为 StatePending。
+func (te *TaskExecutor) Report() []TaskReport {
+	reports := make([]TaskReport, 0, len(te.lastOrder))
+	for _, name := range te.lastOrder {
+		r := TaskReport{Name: name, TaskResult: TaskResult{State: StatePending}}
+		if res, ok := te.lastResults[name]; ok && res != nil {
+			r.TaskResult = *res
+		}
+		reports = append(reports, r)
+	}
+	return reports
+}
+
 func (te *TaskExecutor) GetTaskNames() []string {
 	names := make([]string, 0, len(te.tasks))
 	for name := range te.tasks {
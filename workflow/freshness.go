@@ -0,0 +1,54 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+)
+
+// freshnessTables 列出需要关注最新日期的 raw 表及其日期列，
+// metrics 与运行通知共用这份清单。
+var freshnessTables = []struct {
+	Meta    *model.TableMeta
+	DateCol string
+}{
+	{model.TableKlineDaily, "date"},
+	{model.TableKline1Min, "datetime"},
+	{model.TableBasicDaily, "date"},
+	{model.TableAdjustFactor, "date"},
+	{model.TableValuationDaily, "date"},
+	{model.TableBlockStatsDaily, "date"},
+	{model.TableReturnsDaily, "date"},
+	{model.TableGbbq, "date"},
+}
+
+// TableDate 是某张表当前的最新日期；空表时 Latest 为零值。
+type TableDate struct {
+	Table  string
+	Latest time.Time
+}
+
+// LatestDates 通过 GetLatestDate 查询 freshnessTables 中每张表的最新日期。
+func LatestDates(db database.DataRepository) ([]TableDate, error) {
+	out := make([]TableDate, 0, len(freshnessTables))
+	for _, t := range freshnessTables {
+		latest, err := db.GetLatestDate(t.Meta.TableName, t.DateCol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest date of %s: %w", t.Meta.TableName, err)
+		}
+		out = append(out, TableDate{Table: t.Meta.TableName, Latest: latest})
+	}
+	return out, nil
+}
+
+// HasFailures 报告最近一次 Run 中是否有任务失败（含 ErrorModeSkip 被放行的任务）。
+func HasFailures(reports []TaskReport) bool {
+	for _, r := range reports {
+		if r.State == StateFailed || r.Error != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -15,18 +15,25 @@ type WorkPlan struct {
 	LastTradingDay time.Time
 	Calendar       *TradingCalendar
 
-	NeedDaily    bool
-	NeedGbbq     bool
-	NeedBasic    bool
-	NeedFactor   bool
-	NeedHolidays bool
+	NeedDaily      bool
+	NeedGbbq       bool
+	NeedBasic      bool
+	NeedFactor     bool
+	NeedValuation  bool
+	NeedBlockStats bool
+	NeedHolidays   bool
+
+	// PatchedDaily 是 raw_kline_daily 中由在线行情补齐、等待 g4day 官方数据覆盖的日期。
+	PatchedDaily []time.Time
+	// ExpiredPatches 是超过 officialDailyWindowDays 仍无官方数据的补齐日期，不再等待，由 update_daily 删掉标记。
+	ExpiredPatches []time.Time
 
 	Reason string // 用于日志
 }
 
 // AnyNeeded 是否有任何任务需要执行。
 func (p *WorkPlan) AnyNeeded() bool {
-	return p.NeedDaily || p.NeedGbbq || p.NeedBasic || p.NeedFactor || p.NeedHolidays
+	return p.NeedDaily || p.NeedGbbq || p.NeedBasic || p.NeedFactor || p.NeedValuation || p.NeedBlockStats || p.NeedHolidays
 }
 
 // BuildWorkPlan 读取交易日历与各表最新日期，推导本次 cron 要做什么。
@@ -44,6 +51,8 @@ func BuildWorkPlan(db database.DataRepository, today time.Time) (*WorkPlan, erro
 		plan.NeedGbbq = true
 		plan.NeedBasic = true
 		plan.NeedFactor = true
+		plan.NeedValuation = true
+		plan.NeedBlockStats = true
 		plan.NeedHolidays = true
 		plan.Reason = "🌱 raw_holidays 为空，走完整流程"
 		return plan, nil
@@ -65,6 +74,14 @@ func BuildWorkPlan(db database.DataRepository, today time.Time) (*WorkPlan, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest factor date: %w", err)
 	}
+	valuationLatest, err := db.GetLatestDate(model.TableValuationDaily.TableName, "date")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest valuation date: %w", err)
+	}
+	blockStatsLatest, err := db.GetLatestDate(model.TableBlockStatsDaily.TableName, "date")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block stats date: %w", err)
+	}
 
 	// 空库：交给 init 流程；此处不标任何 Need，调用方自行决定。
 	if dailyLatest.IsZero() {
@@ -72,12 +89,22 @@ func BuildWorkPlan(db database.DataRepository, today time.Time) (*WorkPlan, erro
 		return plan, nil
 	}
 
-	plan.NeedDaily = dailyLatest.Before(plan.LastTradingDay)
+	patched, err := patchedDailyDates(db)
+	if err != nil {
+		return nil, err
+	}
+	plan.PatchedDaily, plan.ExpiredPatches = splitPatchedDaily(patched, plan.LastTradingDay)
+
+	// 有在线补齐的日期时也要尝试下载官方数据把它们覆盖掉。
+	plan.NeedDaily = dailyLatest.Before(plan.LastTradingDay) || len(plan.PatchedDaily) > 0
 	// gbbq 与日线同频：日线没新数据时 gbbq 也无须更新。
 	plan.NeedGbbq = plan.NeedDaily
 	// basic/factor 要追赶 daily；如果 daily 将更新，那之后也必须重算。
 	plan.NeedBasic = plan.NeedDaily || basicLatest.Before(dailyLatest)
 	plan.NeedFactor = plan.NeedDaily || factorLatest.Before(basicLatest)
+	// 估值依赖 basic 的总市值，与 factor 同样追赶 basic。
+	plan.NeedValuation = plan.NeedBasic || valuationLatest.Before(basicLatest)
+	plan.NeedBlockStats = plan.NeedBasic || blockStatsLatest.Before(basicLatest)
 	// holidays 来自 gbbq.zip，与 gbbq 同频刷新即可。
 	plan.NeedHolidays = plan.NeedGbbq
 
@@ -89,13 +116,18 @@ func describePlan(plan *WorkPlan, dailyLatest time.Time) string {
 	dayStr := plan.Today.Format("2006-01-02")
 	cal := plan.Calendar
 
+	if plan.NeedDaily && len(plan.PatchedDaily) > 0 {
+		return fmt.Sprintf("📅 数据库日线最新 %s，其中 %d 天为在线补齐，执行更新",
+			dailyLatest.Format("2006-01-02"), len(plan.PatchedDaily))
+	}
+
 	if plan.NeedDaily {
 		return fmt.Sprintf("📅 数据库日线最新 %s，执行更新",
 			dailyLatest.Format("2006-01-02"))
 	}
 
-	if plan.NeedBasic || plan.NeedFactor {
-		return fmt.Sprintf("📅 日线已是最新 (%s)，补算 basic/factor", dailyLatest.Format("2006-01-02"))
+	if plan.NeedBasic || plan.NeedFactor || plan.NeedValuation || plan.NeedBlockStats {
+		return fmt.Sprintf("📅 日线已是最新 (%s)，补算 basic 及其衍生表", dailyLatest.Format("2006-01-02"))
 	}
 
 	switch {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/jing2uo/tdx2db/database"
 	"github.com/jing2uo/tdx2db/tdx"
+	"github.com/jing2uo/tdx2db/utils"
 )
 
 var TaskUpdate1Min *Task
@@ -19,16 +20,18 @@ func init() {
 		SkipIf: func(ctx context.Context, db database.DataRepository, args *TaskArgs) bool {
 			return !args.Min
 		},
-		Executor: executeUpdate1Min,
-		OnError:  ErrorModeSkip,
+		SkipReason: "未指定 --min",
+		Executor:   executeUpdate1Min,
+		OnError:    ErrorModeSkip,
 	}
 	registerTask(TaskUpdate1Min, "update")
 }
 
 func executeUpdate1Min(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
 	validDates, _ := args.Extra[ExtraTicValidDates].([]time.Time)
 	if len(validDates) == 0 {
-		fmt.Println("🌲 分时数据无需更新")
+		log.Info("🌲 分时数据无需更新")
 		return &TaskResult{State: StateSkipped, Message: "no new 1min data"}, nil
 	}
 
@@ -39,12 +42,12 @@ func executeUpdate1Min(ctx context.Context, db database.DataRepository, args *Ta
 	}
 
 	endDate := validDates[len(validDates)-1]
-	fmt.Printf("🐌 开始转换分钟数据\n")
-	if err := tdx.DatatoolCreate(args.TempDir, "min", endDate); err != nil {
+	log.Info("🐌 开始转换分钟数据", "date", endDate.Format("2006-01-02"))
+	if err := tdx.DatatoolCreate(ctx, args.TempDir, "min", endDate); err != nil {
 		return nil, fmt.Errorf("failed to run DatatoolMinCreate: %w", err)
 	}
 
-	fmt.Printf("🐌 开始转换分时数据\n")
+	log.Info("🐌 开始转换分时数据")
 	stock1MinCSV := filepath.Join(args.TempDir, "1min.csv")
 	if _, err := tdx.ConvertFilesToCSV(ctx, args.VipdocDir, stock1MinCSV, ".01"); err != nil {
 		return nil, fmt.Errorf("failed to convert .01 files to csv: %w", err)
@@ -53,6 +56,6 @@ func executeUpdate1Min(ctx context.Context, db database.DataRepository, args *Ta
 	if err := db.ImportKline1Min(stock1MinCSV); err != nil {
 		return nil, fmt.Errorf("failed to import 1-minute line csv: %w", err)
 	}
-	fmt.Println("📊 分时数据导入成功")
+	log.Info("📊 分时数据导入成功", "dates", len(validDates))
 	return &TaskResult{State: StateCompleted, Message: "1min data imported"}, nil
 }
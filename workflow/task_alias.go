@@ -0,0 +1,49 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+var TaskUpdateSymbolAlias *Task
+
+func init() {
+	TaskUpdateSymbolAlias = &Task{
+		Name:      "update_symbol_alias",
+		DependsOn: []string{"update_symbol_lifecycle"},
+		Executor:  executeUpdateSymbolAlias,
+		OnError:   ErrorModeSkip,
+	}
+	registerTask(TaskUpdateSymbolAlias, "update")
+}
+
+// executeUpdateSymbolAlias 按生命周期推断北交所代码切换并补进 raw_symbol_alias，
+// 不覆盖用户已有的映射。
+func executeUpdateSymbolAlias(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+
+	var lifecycle []model.SymbolLifecycle
+	if err := db.QueryInto(model.RowQuery{Table: model.TableSymbolLifecycle.TableName}, &lifecycle); err != nil {
+		return nil, fmt.Errorf("failed to query symbol lifecycle: %w", err)
+	}
+	var existing []model.SymbolAlias
+	if err := db.QueryInto(model.RowQuery{Table: model.TableSymbolAlias.TableName}, &existing); err != nil {
+		return nil, fmt.Errorf("failed to query symbol aliases: %w", err)
+	}
+
+	merged := model.MergeSymbolAliases(existing, model.SeedSymbolAliases(lifecycle), false)
+	added := len(merged) - len(existing)
+	if added == 0 {
+		return &TaskResult{State: StateSkipped, Message: "no new symbol aliases"}, nil
+	}
+	if err := replaceTableRows(db, model.TableSymbolAlias, filepath.Join(args.TempDir, "symbol_alias.csv"), merged); err != nil {
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("🔗 新增 %d 条代码变更映射", added), "added", added, "rows", len(merged))
+	return &TaskResult{State: StateCompleted, Rows: added, Message: "symbol aliases seeded"}, nil
+}
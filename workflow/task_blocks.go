@@ -24,7 +24,8 @@ func init() {
 }
 
 func executeUpdateBlocks(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
-	fmt.Println("🧩 开始下载板块数据")
+	log := utils.Logger(ctx)
+	log.Info("🧩 开始下载板块数据")
 
 	blockInfos, blockMembers, err := tdx.FetchOnlineBlocks(ctx)
 	if err != nil {
@@ -61,6 +62,7 @@ func executeUpdateBlocks(ctx context.Context, db database.DataRepository, args *
 	}
 
 	msg := fmt.Sprintf("blocks imported: %d info rows, %d member rows", len(blockInfos), len(blockMembers))
-	fmt.Printf("🚀 板块数据导入成功\n")
+	log.Info("🚀 板块数据导入成功", "rows", len(blockInfos)+len(blockMembers),
+		"blocks", len(blockInfos), "members", len(blockMembers))
 	return &TaskResult{State: StateCompleted, Rows: len(blockInfos) + len(blockMembers), Message: msg}, nil
 }
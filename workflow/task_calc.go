@@ -4,37 +4,94 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/jing2uo/tdx2db/calc"
 	"github.com/jing2uo/tdx2db/database"
 	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
 )
 
 var (
-	TaskCalcBasic  *Task
-	TaskCalcFactor *Task
+	TaskCalcBasic      *Task
+	TaskCalcFactor     *Task
+	TaskCalcValuation  *Task
+	TaskCalcBlockStats *Task
+	TaskCalcStitched   *Task
+	TaskCalcReturns    *Task
 )
 
 func init() {
 	TaskCalcBasic = &Task{
-		Name:      "calc_basic",
-		DependsOn: []string{"update_daily", "update_gbbq"},
-		SkipIf:    skipIfPlan(func(p *WorkPlan) bool { return !p.NeedBasic }),
-		Executor:  executeCalcBasic,
+		Name:       "calc_basic",
+		DependsOn:  []string{"update_daily", "fill_daily_online", "update_gbbq"},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedBasic }),
+		SkipReason: "plan.NeedBasic=false，basic 已追平日线",
+		Executor:   executeCalcBasic,
 	}
 	registerTask(TaskCalcBasic, "update")
 
 	TaskCalcFactor = &Task{
-		Name:      "calc_factor",
-		DependsOn: []string{"calc_basic"},
-		SkipIf:    skipIfPlan(func(p *WorkPlan) bool { return !p.NeedFactor }),
-		Executor:  executeCalcFactor,
+		Name:       "calc_factor",
+		DependsOn:  []string{"calc_basic"},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedFactor }),
+		SkipReason: "plan.NeedFactor=false，复权因子已追平 basic",
+		Executor:   executeCalcFactor,
 	}
 	registerTask(TaskCalcFactor, "update")
+
+	// 财务数据与 gbbq 更新失败时仍用库里已有的数据计算估值。
+	TaskCalcValuation = &Task{
+		Name:       "calc_valuation",
+		DependsOn:  []string{"calc_basic", "update_finance", "update_gbbq"},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedValuation }),
+		SkipReason: "plan.NeedValuation=false，估值已追平 basic",
+		Executor:   executeCalcValuation,
+		OnError:    ErrorModeSkip,
+	}
+	registerTask(TaskCalcValuation, "update")
+
+	TaskCalcBlockStats = &Task{
+		Name:       "calc_block_stats",
+		DependsOn:  []string{"calc_basic", "update_blocks"},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedBlockStats }),
+		SkipReason: "plan.NeedBlockStats=false，板块统计已追平 basic",
+		Executor:   executeCalcBlockStats,
+		OnError:    ErrorModeSkip,
+	}
+	registerTask(TaskCalcBlockStats, "update")
+
+	TaskCalcStitched = &Task{
+		Name:       "calc_stitched",
+		DependsOn:  []string{"calc_basic", "update_symbol_alias"},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedFactor }),
+		SkipReason: "plan.NeedFactor=false，拼接序列已追平 basic",
+		Executor:   executeCalcStitched,
+		OnError:    ErrorModeSkip,
+	}
+	registerTask(TaskCalcStitched, "update")
+
+	// 前瞻收益随新日线补全，每次全量重算；窗口按交易日历计数。表为空 (刚升级) 时补算一次。
+	TaskCalcReturns = &Task{
+		Name:      "calc_returns",
+		DependsOn: []string{"calc_factor", "update_trade_calendar"},
+		SkipIf: func(ctx context.Context, db database.DataRepository, args *TaskArgs) bool {
+			if args.Plan == nil || args.Plan.NeedFactor {
+				return false
+			}
+			latest, err := db.GetLatestDate(model.TableReturnsDaily.TableName, "date")
+			return err == nil && !latest.IsZero()
+		},
+		SkipReason: "plan.NeedFactor=false，收益率已追平复权因子",
+		Executor:   executeCalcReturns,
+		OnError:    ErrorModeSkip,
+	}
+	registerTask(TaskCalcReturns, "update")
 }
 
 func executeCalcBasic(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
-	fmt.Println("📟 计算股票基础行情")
+	log := utils.Logger(ctx)
+	log.Info("📟 计算股票基础行情")
 	basicCSV := filepath.Join(args.TempDir, "basics.csv")
 
 	rowCount, err := calc.ExportBasicDailyToCSV(ctx, db, basicCSV)
@@ -43,7 +100,7 @@ func executeCalcBasic(ctx context.Context, db database.DataRepository, args *Tas
 	}
 
 	if rowCount == 0 {
-		fmt.Println("🌲 股票基础行情无需更新")
+		log.Info("🌲 股票基础行情无需更新")
 		return &TaskResult{State: StateSkipped, Message: "no new basic data"}, nil
 	}
 
@@ -51,12 +108,13 @@ func executeCalcBasic(ctx context.Context, db database.DataRepository, args *Tas
 	if err := db.ImportBasic(basicCSV); err != nil {
 		return nil, fmt.Errorf("failed to import basic data: %w", err)
 	}
-	fmt.Println("🔢 基础行情导入成功")
+	log.Info("🔢 基础行情导入成功", "rows", rowCount)
 	return &TaskResult{State: StateCompleted, Rows: rowCount, Message: "basic data calculated"}, nil
 }
 
 func executeCalcFactor(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
-	fmt.Println("📟 计算股票复权因子")
+	log := utils.Logger(ctx)
+	log.Info("📟 计算股票复权因子")
 	factorCSV := filepath.Join(args.TempDir, "factor.csv")
 
 	factorCount, err := calc.ExportFactorsToCSV(ctx, db, factorCSV)
@@ -65,7 +123,7 @@ func executeCalcFactor(ctx context.Context, db database.DataRepository, args *Ta
 	}
 
 	if factorCount == 0 {
-		fmt.Println("🌲 复权因子无需更新")
+		log.Info("🌲 复权因子无需更新")
 		return &TaskResult{State: StateSkipped, Message: "no new factor data"}, nil
 	}
 
@@ -73,6 +131,172 @@ func executeCalcFactor(ctx context.Context, db database.DataRepository, args *Ta
 	if err := db.ImportAdjustFactors(factorCSV); err != nil {
 		return nil, fmt.Errorf("failed to append factor data: %w", err)
 	}
-	fmt.Printf("🔢 复权因子导入成功\n")
+	log.Info("🔢 复权因子导入成功", "rows", factorCount)
 	return &TaskResult{State: StateCompleted, Rows: factorCount, Message: "factors calculated"}, nil
 }
+
+func executeCalcValuation(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+	log.Info("📟 计算个股每日估值")
+	valuationCSV := filepath.Join(args.TempDir, "valuation.csv")
+
+	rowCount, err := calc.ExportValuationDailyToCSV(ctx, db, valuationCSV)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export valuation to csv: %w", err)
+	}
+
+	if rowCount == 0 {
+		log.Info("🌲 估值数据无需更新")
+		return &TaskResult{State: StateSkipped, Message: "no new valuation data"}, nil
+	}
+
+	db.TruncateTable(model.TableValuationDaily)
+	if err := db.ImportCSV(model.TableValuationDaily, valuationCSV); err != nil {
+		return nil, fmt.Errorf("failed to import valuation data: %w", err)
+	}
+	log.Info("🔢 估值数据导入成功", "rows", rowCount)
+	return &TaskResult{State: StateCompleted, Rows: rowCount, Message: "valuation calculated"}, nil
+}
+
+// executeCalcBlockStats 追加 raw_block_stats_daily 最新日期之后的交易日；已算过的日期保留当时的成分，
+// 不随板块成分更新重算。但 basic 变了的日期 (仍在等待官方数据的在线补齐日、本轮官方数据覆盖或
+// 补齐的缺口日) 从其中最早的一天起删掉重算，重算部分按当前成分计算。
+func executeCalcBlockStats(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+	log.Info("📟 计算板块成分统计")
+	statsCSV := filepath.Join(args.TempDir, "block_stats.csv")
+
+	latest, err := db.GetLatestDate(model.TableBlockStatsDaily.TableName, "date")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block stats date: %w", err)
+	}
+	since := rewindForChangedDaily(latest, args)
+
+	rowCount, err := calc.ExportBlockStatsToCSV(ctx, db, statsCSV, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export block stats to csv: %w", err)
+	}
+
+	if rowCount == 0 {
+		log.Info("🌲 板块统计无需更新")
+		return &TaskResult{State: StateSkipped, Message: "no new block stats"}, nil
+	}
+
+	if since.Before(latest) {
+		var stale []time.Time
+		err := db.QueryInto(model.RowQuery{
+			Table:   model.TableBlockStatsDaily.TableName,
+			Columns: []string{"DISTINCT date AS date"},
+			Where:   []model.Cond{{Column: "date", Op: ">", Value: since}},
+		}, &stale)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query block stats dates: %w", err)
+		}
+		if err := db.DeleteDates(model.TableBlockStatsDaily, "date", stale); err != nil {
+			return nil, err
+		}
+		log.Info(fmt.Sprintf("♻️ 日线有变动，从 %s 起重算板块统计", since.AddDate(0, 0, 1).Format("2006-01-02")),
+			"dates", len(stale))
+	}
+
+	if err := db.ImportCSV(model.TableBlockStatsDaily, statsCSV); err != nil {
+		return nil, fmt.Errorf("failed to import block stats: %w", err)
+	}
+	log.Info("🔢 板块统计导入成功", "rows", rowCount)
+	return &TaskResult{State: StateCompleted, Rows: rowCount, Message: "block stats calculated"}, nil
+}
+
+// rewindForChangedDaily 把增量起点 latest 退到 basic 可能变动的最早日期之前一天：
+// 计划中的在线补齐日期与本轮导入的日线日期里不晚于 latest 的。
+func rewindForChangedDaily(latest time.Time, args *TaskArgs) time.Time {
+	if latest.IsZero() {
+		return latest
+	}
+	changed := append([]time.Time(nil), args.ImportedDaily...)
+	if args.Plan != nil {
+		changed = append(changed, args.Plan.PatchedDaily...)
+	}
+	since := latest
+	for _, d := range changed {
+		if prev := d.AddDate(0, 0, -1); prev.Before(since) {
+			since = prev
+		}
+	}
+	return since
+}
+
+func executeCalcReturns(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+	log.Info("📟 计算每日收益率", "benchmark", args.Benchmark)
+	returnsCSV := filepath.Join(args.TempDir, "returns.csv")
+
+	holidays, err := db.GetHolidays()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holidays: %w", err)
+	}
+	start, err := db.GetMinDate(model.TableKlineDaily.TableName, "date")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get first kline date: %w", err)
+	}
+	end, err := db.GetLatestDate(model.TableKlineDaily.TableName, "date")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest kline date: %w", err)
+	}
+	if end.IsZero() {
+		return &TaskResult{State: StateSkipped, Message: "no kline data"}, nil
+	}
+	days := NewTradingCalendar(holidays).TradingDays(start, end)
+
+	rowCount, err := calc.ExportReturnsDailyToCSV(ctx, db, returnsCSV, args.Benchmark, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export returns to csv: %w", err)
+	}
+
+	if rowCount == 0 {
+		log.Info("🌲 收益率无需更新")
+		return &TaskResult{State: StateSkipped, Message: "no returns data"}, nil
+	}
+
+	db.TruncateTable(model.TableReturnsDaily)
+	if err := db.ImportCSV(model.TableReturnsDaily, returnsCSV); err != nil {
+		return nil, fmt.Errorf("failed to import returns: %w", err)
+	}
+	log.Info("🔢 收益率导入成功", "rows", rowCount)
+	return &TaskResult{State: StateCompleted, Rows: rowCount, Message: "returns calculated"}, nil
+}
+
+// executeCalcStitched 按 raw_symbol_alias 全量重算拼接序列；映射被删光时清空 raw_stitched_daily。
+func executeCalcStitched(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+	log.Info("📟 计算代码变更拼接序列")
+
+	rowCount, err := RebuildStitchedDaily(ctx, db, args.TempDir)
+	if err != nil {
+		return nil, err
+	}
+	if rowCount == 0 {
+		log.Info("🌲 没有需要拼接的代码变更")
+		return &TaskResult{State: StateSkipped, Message: "no symbol aliases"}, nil
+	}
+	log.Info("🔢 拼接序列导入成功", "rows", rowCount)
+	return &TaskResult{State: StateCompleted, Rows: rowCount, Message: "stitched daily calculated"}, nil
+}
+
+// RebuildStitchedDaily 重算并替换 raw_stitched_daily，返回写入的行数。
+// 供 calc_stitched 与 tdx2db alias 导入映射后共用。
+func RebuildStitchedDaily(ctx context.Context, db database.DataRepository, tempDir string) (int, error) {
+	stitchedCSV := filepath.Join(tempDir, "stitched_daily.csv")
+	rowCount, err := calc.ExportStitchedDailyToCSV(ctx, db, stitchedCSV)
+	if err != nil {
+		return 0, fmt.Errorf("failed to export stitched daily to csv: %w", err)
+	}
+
+	db.TruncateTable(model.TableStitchedDaily)
+	if rowCount == 0 {
+		return 0, nil
+	}
+	if err := db.ImportCSV(model.TableStitchedDaily, stitchedCSV); err != nil {
+		return 0, fmt.Errorf("failed to import stitched daily: %w", err)
+	}
+	return rowCount, nil
+}
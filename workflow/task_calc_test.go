@@ -0,0 +1,29 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRewindForChangedDaily(t *testing.T) {
+	latest := day("2024-01-10")
+	args := &TaskArgs{
+		Plan: &WorkPlan{PatchedDaily: []time.Time{day("2024-01-08")}},
+		// 1/5 为本轮官方数据覆盖的补齐日，1/11 为新日期。
+		ImportedDaily: []time.Time{day("2024-01-05"), day("2024-01-11")},
+	}
+	if got := rewindForChangedDaily(latest, args); !got.Equal(day("2024-01-04")) {
+		t.Fatalf("expected rewind to 2024-01-04, got %s", got.Format("2006-01-02"))
+	}
+
+	// 只有新日期时保持增量。
+	args = &TaskArgs{ImportedDaily: []time.Time{day("2024-01-11")}}
+	if got := rewindForChangedDaily(latest, args); !got.Equal(latest) {
+		t.Fatalf("expected no rewind, got %s", got.Format("2006-01-02"))
+	}
+
+	// 空表全量计算。
+	if got := rewindForChangedDaily(time.Time{}, args); !got.IsZero() {
+		t.Fatalf("expected zero since for empty table, got %s", got)
+	}
+}
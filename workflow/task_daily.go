@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/jing2uo/tdx2db/database"
 	"github.com/jing2uo/tdx2db/model"
@@ -18,10 +19,11 @@ var (
 
 func init() {
 	TaskUpdateDaily = &Task{
-		Name:      "update_daily",
-		DependsOn: []string{},
-		SkipIf:    skipIfPlan(func(p *WorkPlan) bool { return !p.NeedDaily }),
-		Executor:  executeUpdateDaily,
+		Name:       "update_daily",
+		DependsOn:  []string{},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedDaily }),
+		SkipReason: "plan.NeedDaily=false，日线已是最新",
+		Executor:   executeUpdateDaily,
 	}
 	registerTask(TaskUpdateDaily, "update")
 
@@ -38,21 +40,35 @@ func executeUpdateDaily(ctx context.Context, db database.DataRepository, args *T
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest date from database: %w", err)
 	}
-	fmt.Printf("📅 日线数据最新日期为 %s\n", latestDate.Format("2006-01-02"))
-
-	src := pullSource{
-		targetDir:   filepath.Join(args.VipdocDir, "refmhq"),
-		urlTemplate: "https://www.tdx.com.cn/products/data/data/g4day/%s.zip",
-		fileSuffix:  "day",
-		label:       "日线",
+	log := utils.Logger(ctx)
+	log.Info(fmt.Sprintf("📅 日线数据最新日期为 %s", latestDate.Format("2006-01-02")),
+		"date", latestDate.Format("2006-01-02"))
+
+	since := dailySince(latestDate, args.Plan)
+	var patched, expired []time.Time
+	if args.Plan != nil {
+		patched, expired = args.Plan.PatchedDaily, args.Plan.ExpiredPatches
+	}
+	if len(expired) > 0 {
+		if err := db.DeleteDates(model.TableKlineDailyPatch, "date", expired); err != nil {
+			return nil, err
+		}
+		log.Warn(fmt.Sprintf("⚠️ %d 天在线补齐日线超过 %d 天仍无官方数据，不再等待", len(expired), officialDailyWindowDays),
+			"dates", len(expired))
 	}
-	validDates, err := pullDateRange(ctx, latestDate, src, args)
+	if since.Before(latestDate) {
+		log.Info(fmt.Sprintf("🩹 %d 天日线为在线补齐，从 %s 起重新下载官方数据",
+			len(patched), patched[0].Format("2006-01-02")), "patched", len(patched))
+	}
+
+	src := dailySource(args)
+	validDates, err := pullDateRange(ctx, since, src, args)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch daily data: %w", err)
 	}
 
 	if len(validDates) == 0 {
-		fmt.Println("🌲 日线数据无需更新")
+		log.Info("🌲 日线数据无需更新")
 		return &TaskResult{State: StateSkipped, Message: "no new daily data"}, nil
 	}
 
@@ -63,24 +79,45 @@ func executeUpdateDaily(ctx context.Context, db database.DataRepository, args *T
 	}
 
 	endDate := validDates[len(validDates)-1]
-	if err := tdx.DatatoolCreate(args.TempDir, "day", endDate); err != nil {
+	if err := tdx.DatatoolCreate(ctx, args.TempDir, "day", endDate); err != nil {
 		return nil, fmt.Errorf("failed to run DatatoolDayCreate: %w", err)
 	}
 
-	return executeDailyImport(ctx, db, args, args.VipdocDir)
+	// 转换成功后才替换库中的行，下载或转换失败时在线补齐数据与标记原样保留。
+	result, err := executeDailyImport(ctx, db, args, args.VipdocDir, func(csvPath string) error {
+		return replaceDaily(ctx, db, csvPath, args.TempDir, latestDate, patched, validDates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	args.ImportedDaily = append(args.ImportedDaily, validDates...)
+	return result, nil
+}
+
+// dailySince 返回 g4day 下载起点 (不含)：在线补齐的日期不算官方数据，从最早的补齐日期重新下载。
+// update_daily 与 dry-run 探测共用。
+func dailySince(latest time.Time, plan *WorkPlan) time.Time {
+	if plan != nil && len(plan.PatchedDaily) > 0 {
+		if d := plan.PatchedDaily[0].AddDate(0, 0, -1); d.Before(latest) {
+			return d
+		}
+	}
+	return latest
 }
 
 func executeInitDaily(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
-	fmt.Printf("📦 开始处理日线目录: %s\n", args.DayFileDir)
+	utils.Logger(ctx).Info(fmt.Sprintf("📦 开始处理日线目录: %s", args.DayFileDir), "dir", args.DayFileDir)
 	if err := utils.CheckDirectory(args.DayFileDir); err != nil {
 		return nil, err
 	}
 
-	return executeDailyImport(ctx, db, args, args.DayFileDir)
+	return executeDailyImport(ctx, db, args, args.DayFileDir, db.ImportKlineDaily)
 }
 
-func executeDailyImport(ctx context.Context, db database.DataRepository, args *TaskArgs, sourceDir string) (*TaskResult, error) {
-	fmt.Println("🐌 开始转换日线数据")
+// executeDailyImport 转换 sourceDir 下的 .day 文件，再交给 importCSV 导入。
+func executeDailyImport(ctx context.Context, db database.DataRepository, args *TaskArgs, sourceDir string, importCSV func(csvPath string) error) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+	log.Info("🐌 开始转换日线数据")
 
 	stockDailyCSV := filepath.Join(args.TempDir, "stock.csv")
 
@@ -95,7 +132,7 @@ func executeDailyImport(ctx context.Context, db database.DataRepository, args *T
 	default:
 	}
 
-	if err := db.ImportKlineDaily(stockDailyCSV); err != nil {
+	if err := importCSV(stockDailyCSV); err != nil {
 		return nil, fmt.Errorf("failed to import stock csv: %w", err)
 	}
 
@@ -103,6 +140,6 @@ func executeDailyImport(ctx context.Context, db database.DataRepository, args *T
 		return nil, fmt.Errorf("failed to rebuild symbol_class: %w", err)
 	}
 
-	fmt.Println("🚀 股票数据导入成功")
+	log.Info("🚀 股票数据导入成功")
 	return &TaskResult{State: StateCompleted, Message: "daily data imported"}, nil
 }
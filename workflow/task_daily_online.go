@@ -0,0 +1,262 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/tdx"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+var TaskFillDailyOnline *Task
+
+func init() {
+	TaskFillDailyOnline = &Task{
+		Name:      "fill_daily_online",
+		DependsOn: []string{"update_daily"},
+		SkipIf: func(ctx context.Context, db database.DataRepository, args *TaskArgs) bool {
+			return !args.OnlineFill || args.Plan == nil || args.Plan.Calendar == nil
+		},
+		SkipReason: "未指定 --online-fill",
+		Executor:   executeFillDailyOnline,
+		OnError:    ErrorModeSkip,
+	}
+	registerTask(TaskFillDailyOnline, "update")
+}
+
+// 收盘后留几分钟余量，避免取到盘中未定型的当日 K 线。
+const marketCloseHour, marketCloseMinute = 15, 5
+
+var chinaTZ = time.FixedZone("CST", 8*3600)
+
+// officialDailyWindowDays 是等待 g4day 官方数据的天数：补齐日期超过该期限仍未发布时
+// 不再重新下载，保留在线数据；在线补齐也只回看这么多天的缺口。
+const officialDailyWindowDays = 14
+
+// patchedDailyDates 返回等待官方数据覆盖的在线补齐日期，升序。
+func patchedDailyDates(db database.DataRepository) ([]time.Time, error) {
+	var rows []model.KlineDailyPatch
+	err := db.QueryInto(model.RowQuery{
+		Table:   model.TableKlineDailyPatch.TableName,
+		OrderBy: []string{"date"},
+	}, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load patched daily dates: %w", err)
+	}
+	dates := make([]time.Time, len(rows))
+	for i, r := range rows {
+		dates[i] = r.Date
+	}
+	return dates, nil
+}
+
+// splitPatchedDaily 按 officialDailyWindowDays 把补齐日期分为仍在等待官方数据的与已过期的。
+func splitPatchedDaily(patched []time.Time, today time.Time) (active, expired []time.Time) {
+	cutoff := today.AddDate(0, 0, -officialDailyWindowDays)
+	for _, d := range patched {
+		if d.Before(cutoff) {
+			expired = append(expired, d)
+		} else {
+			active = append(active, d)
+		}
+	}
+	return active, expired
+}
+
+// replaceDaily 导入官方日线 CSV，并替换库中将被覆盖的行：重新下载的日期里不晚于 latest 的
+// (在线补齐的日期，以及从最早补齐日期起顺带重新下载的官方日期) 都已在库中，直接导入会重复。
+// 导入失败时写回删掉的行，补齐标记也只在导入成功后删除，下次运行仍会重新下载。
+func replaceDaily(ctx context.Context, db database.DataRepository, csvPath, tempDir string, latest time.Time, patched, official []time.Time) error {
+	var existing []time.Time
+	set := make(map[string]bool, len(official))
+	for _, d := range official {
+		set[d.Format("2006-01-02")] = true
+		if !d.After(latest) {
+			existing = append(existing, d)
+		}
+	}
+	var overlap []time.Time
+	for _, d := range patched {
+		if set[d.Format("2006-01-02")] {
+			overlap = append(overlap, d)
+		}
+	}
+
+	var backup []model.KlineDay
+	if len(existing) > 0 {
+		var rows []model.KlineDay
+		err := db.QueryInto(model.RowQuery{
+			Table: model.TableKlineDaily.TableName,
+			Where: []model.Cond{
+				{Column: "date", Op: ">=", Value: existing[0]},
+				{Column: "date", Op: "<=", Value: latest},
+			},
+		}, &rows)
+		if err != nil {
+			return fmt.Errorf("failed to back up replaced daily rows: %w", err)
+		}
+		for _, r := range rows {
+			if set[r.Date.Format("2006-01-02")] {
+				backup = append(backup, r)
+			}
+		}
+		if err := db.DeleteDates(model.TableKlineDaily, "date", existing); err != nil {
+			return err
+		}
+	}
+
+	if err := db.ImportKlineDaily(csvPath); err != nil {
+		if len(backup) == 0 {
+			return err
+		}
+		backupCSV := filepath.Join(tempDir, "daily_backup.csv")
+		if werr := writeCSV(backupCSV, backup); werr != nil {
+			return errors.Join(err, werr)
+		}
+		if rerr := db.ImportKlineDaily(backupCSV); rerr != nil {
+			return errors.Join(err, fmt.Errorf("failed to restore replaced daily rows: %w", rerr))
+		}
+		return err
+	}
+
+	if err := db.DeleteDates(model.TableKlineDailyPatch, "date", overlap); err != nil {
+		return err
+	}
+	if len(overlap) > 0 {
+		utils.Logger(ctx).Info(fmt.Sprintf("🩹 %d 天在线补齐日线已由官方数据覆盖", len(overlap)), "dates", len(overlap))
+	}
+	return nil
+}
+
+// dailyDatesSince 返回 raw_kline_daily 中不早于 start 的日期集合。
+func dailyDatesSince(db database.DataRepository, start time.Time) (map[string]bool, error) {
+	var dates []time.Time
+	err := db.QueryInto(model.RowQuery{
+		Table:   model.TableKlineDaily.TableName,
+		Columns: []string{"DISTINCT date AS date"},
+		Where:   []model.Cond{{Column: "date", Op: ">=", Value: start}},
+	}, &dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily dates: %w", err)
+	}
+	have := make(map[string]bool, len(dates))
+	for _, d := range dates {
+		have[d.Format("2006-01-02")] = true
+	}
+	return have, nil
+}
+
+// missingTradingDates 返回 [start, lastTradingDay] 内 raw_kline_daily 没有任何行的交易日
+// (have 为已有日期)；g4day 某天 404 而之后的日期已发布时，缺口夹在已有日期之间，同样会被补齐。
+// 当天未收盘时不含当天。
+func missingTradingDates(cal *TradingCalendar, have map[string]bool, start, lastTradingDay time.Time, now time.Time) []time.Time {
+	local := now.In(chinaTZ)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	closed := local.Hour() > marketCloseHour ||
+		(local.Hour() == marketCloseHour && local.Minute() >= marketCloseMinute)
+
+	var dates []time.Time
+	for _, d := range cal.TradingDays(start, lastTradingDay) {
+		if have[d.Format("2006-01-02")] {
+			continue
+		}
+		if d.Equal(today) && !closed {
+			continue
+		}
+		dates = append(dates, d)
+	}
+	return dates
+}
+
+func executeFillDailyOnline(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+
+	first, err := db.GetMinDate(model.TableKlineDaily.TableName, "date")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get first daily date: %w", err)
+	}
+	start := args.Plan.LastTradingDay.AddDate(0, 0, -officialDailyWindowDays)
+	if start.Before(first) {
+		start = first
+	}
+	have, err := dailyDatesSince(db, start)
+	if err != nil {
+		return nil, err
+	}
+	dates := missingTradingDates(args.Plan.Calendar, have, start, args.Plan.LastTradingDay, time.Now())
+	if len(dates) == 0 {
+		log.Info("🌲 日线无缺口，无需在线补齐")
+		return &TaskResult{State: StateSkipped, Message: "no missing daily dates"}, nil
+	}
+
+	symbols, err := db.GetSymbolsByClass(
+		model.ClassStock, model.ClassBStock, model.ClassETF, model.ClassIndex, model.ClassBlock, model.ClassCBond,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info(fmt.Sprintf("🛰️ 在线补齐日线 %s ~ %s", dates[0].Format("2006-01-02"), dates[len(dates)-1].Format("2006-01-02")),
+		"dates", len(dates), "symbols", len(symbols))
+	rows, err := tdx.FetchOnlineDailyBars(ctx, symbols, dates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch online daily bars: %w", err)
+	}
+	if len(rows) == 0 {
+		log.Info("🌲 在线行情暂无缺口日期数据")
+		return &TaskResult{State: StateSkipped, Message: "no online daily bars"}, nil
+	}
+
+	// 只标记实际拿到数据的日期，没数据的日期留给下次补齐。
+	got := map[string]time.Time{}
+	for _, r := range rows {
+		got[r.Date.Format("2006-01-02")] = r.Date
+	}
+	now := time.Now()
+	patches := make([]model.KlineDailyPatch, 0, len(got))
+	for _, d := range dates {
+		if _, ok := got[d.Format("2006-01-02")]; ok {
+			patches = append(patches, model.KlineDailyPatch{Date: d, Source: "online", FilledAt: now})
+		}
+	}
+
+	dailyCSV := filepath.Join(args.TempDir, "online_daily.csv")
+	if err := writeCSV(dailyCSV, rows); err != nil {
+		return nil, err
+	}
+	patchCSV := filepath.Join(args.TempDir, "online_daily_patch.csv")
+	if err := writeCSV(patchCSV, patches); err != nil {
+		return nil, err
+	}
+
+	if err := db.ImportKlineDaily(dailyCSV); err != nil {
+		return nil, fmt.Errorf("failed to import online daily bars: %w", err)
+	}
+	if err := db.ImportCSV(model.TableKlineDailyPatch, patchCSV); err != nil {
+		return nil, fmt.Errorf("failed to import daily patch marks: %w", err)
+	}
+
+	for _, p := range patches {
+		args.ImportedDaily = append(args.ImportedDaily, p.Date)
+	}
+
+	log.Info("🩹 在线日线补齐完成，待官方数据发布后覆盖", "rows", len(rows), "dates", len(patches))
+	return &TaskResult{State: StateCompleted, Rows: len(rows), Message: "online daily bars filled"}, nil
+}
+
+func writeCSV[T any](path string, rows []T) error {
+	cw, err := utils.NewCSVWriter[T](path)
+	if err != nil {
+		return fmt.Errorf("failed to create csv %s: %w", path, err)
+	}
+	if err := cw.Write(rows); err != nil {
+		cw.Close()
+		return fmt.Errorf("failed to write csv %s: %w", path, err)
+	}
+	return cw.Close()
+}
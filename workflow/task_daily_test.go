@@ -0,0 +1,216 @@
+package workflow
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+)
+
+func day(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func openTestDB(t *testing.T) database.DataRepository {
+	t.Helper()
+	db, err := database.NewDB("duckdb://" + filepath.Join(t.TempDir(), "tdx.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func dailyCSV(t *testing.T, dates ...string) string {
+	t.Helper()
+	rows := make([]model.KlineDay, len(dates))
+	for i, d := range dates {
+		rows[i] = model.KlineDay{Symbol: "sz000001", Open: 10, High: 10, Low: 10, Close: 10, Date: day(d)}
+	}
+	path := filepath.Join(t.TempDir(), "daily.csv")
+	if err := writeCSV(path, rows); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func importDaily(t *testing.T, db database.DataRepository, dates ...string) {
+	t.Helper()
+	if err := db.ImportKlineDaily(dailyCSV(t, dates...)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// markPatched 写入 1/3 的在线补齐标记并返回当前补齐日期。
+func markPatched(t *testing.T, db database.DataRepository) []time.Time {
+	t.Helper()
+	patchCSV := filepath.Join(t.TempDir(), "patch.csv")
+	if err := writeCSV(patchCSV, []model.KlineDailyPatch{{Date: day("2024-01-03"), Source: "online", FilledAt: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ImportCSV(model.TableKlineDailyPatch, patchCSV); err != nil {
+		t.Fatal(err)
+	}
+	patched, err := patchedDailyDates(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patched
+}
+
+func dailyCounts(t *testing.T, db database.DataRepository) map[string]int {
+	t.Helper()
+	rows, err := db.QueryKlineDaily("sz000001", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, r := range rows {
+		counts[r.Date.Format("2006-01-02")]++
+	}
+	return counts
+}
+
+// 1/3 在线补齐后 g4day 一直 404，1/4 之后的官方数据照常发布：
+// 从 1/3 起重新下载的官方日期不能重复导入，1/3 发布后补齐行被替换、标记清除。
+func TestReplaceDailyAvoidsDuplicates(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	importDaily(t, db, "2024-01-02", "2024-01-03", "2024-01-04")
+	patched := markPatched(t, db)
+
+	// 第一轮：1/3 仍是 404，只下载到 1/4、1/5。
+	official := []time.Time{day("2024-01-04"), day("2024-01-05")}
+	csv := dailyCSV(t, "2024-01-04", "2024-01-05")
+	if err := replaceDaily(ctx, db, csv, t.TempDir(), day("2024-01-04"), patched, official); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"2024-01-02": 1, "2024-01-03": 1, "2024-01-04": 1, "2024-01-05": 1}
+	if got := dailyCounts(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after first run: got %v, want %v", got, want)
+	}
+	if patched, _ = patchedDailyDates(db); len(patched) != 1 {
+		t.Fatalf("patch mark of 2024-01-03 should remain, got %v", patched)
+	}
+
+	// 第二轮：1/3 官方数据发布。
+	official = []time.Time{day("2024-01-03"), day("2024-01-04"), day("2024-01-05")}
+	csv = dailyCSV(t, "2024-01-03", "2024-01-04", "2024-01-05")
+	if err := replaceDaily(ctx, db, csv, t.TempDir(), day("2024-01-05"), patched, official); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := dailyCounts(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after second run: got %v, want %v", got, want)
+	}
+	if patched, _ = patchedDailyDates(db); len(patched) != 0 {
+		t.Fatalf("patch marks should be cleared, got %v", patched)
+	}
+}
+
+// 官方数据导入失败或中途取消时，在线补齐的行与标记都要保留，下次运行再重新下载。
+func TestDailyImportFailureKeepsPatchedRows(t *testing.T) {
+	db := openTestDB(t)
+	importDaily(t, db, "2024-01-02", "2024-01-03", "2024-01-04")
+	patched := markPatched(t, db)
+	official := []time.Time{day("2024-01-03"), day("2024-01-04"), day("2024-01-05")}
+	want := map[string]int{"2024-01-02": 1, "2024-01-03": 1, "2024-01-04": 1}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for name, ctx := range map[string]context.Context{"import fails": context.Background(), "cancelled": cancelled} {
+		// 空目录转换不出 CSV，导入必然失败。
+		args := &TaskArgs{TempDir: t.TempDir()}
+		_, err := executeDailyImport(ctx, db, args, t.TempDir(), func(csvPath string) error {
+			return replaceDaily(ctx, db, csvPath, args.TempDir, day("2024-01-04"), patched, official)
+		})
+		if err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+		if got := dailyCounts(t, db); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %v, want %v", name, got, want)
+		}
+		if got, _ := patchedDailyDates(db); len(got) != 1 {
+			t.Fatalf("%s: patch mark should remain, got %v", name, got)
+		}
+	}
+}
+
+func TestSplitPatchedDaily(t *testing.T) {
+	patched := []time.Time{day("2024-01-03"), day("2024-01-17")}
+	active, expired := splitPatchedDaily(patched, day("2024-01-19"))
+	if len(active) != 1 || !active[0].Equal(day("2024-01-17")) || len(expired) != 1 || !expired[0].Equal(day("2024-01-03")) {
+		t.Fatalf("unexpected split: active %v, expired %v", active, expired)
+	}
+}
+
+// g4day 的 1/3 缺失而 1/4 已导入时，1/3 夹在已有日期之间也要补齐。
+func TestMissingTradingDatesFindsGaps(t *testing.T) {
+	cal := NewTradingCalendar([]time.Time{day("2024-01-01")})
+	have := map[string]bool{"2024-01-02": true, "2024-01-04": true}
+	now := time.Date(2024, 1, 5, 16, 0, 0, 0, chinaTZ)
+
+	got := missingTradingDates(cal, have, day("2024-01-01"), day("2024-01-05"), now)
+	want := []time.Time{day("2024-01-03"), day("2024-01-05")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// 盘中不补当天。
+	now = time.Date(2024, 1, 5, 14, 0, 0, 0, chinaTZ)
+	got = missingTradingDates(cal, have, day("2024-01-01"), day("2024-01-05"), now)
+	if !reflect.DeepEqual(got, want[:1]) {
+		t.Fatalf("intraday: got %v, want %v", got, want[:1])
+	}
+}
+
+// init 只跑 init 组任务，导入日线后必须建好 raw_symbol_lifecycle，否则填充视图为空。
+func TestInitBuildsSymbolLifecycle(t *testing.T) {
+	found := false
+	for _, name := range GetInitTaskNames() {
+		found = found || name == TaskInitSymbolLifecycle.Name
+	}
+	if !found {
+		t.Fatalf("init tasks %v do not build symbol lifecycle", GetInitTaskNames())
+	}
+
+	db := openTestDB(t)
+	importDaily(t, db, "2024-01-02", "2024-01-04")
+	if err := db.RebuildSymbolClass(); err != nil {
+		t.Fatal(err)
+	}
+	args := &TaskArgs{Today: day("2024-01-04"), TempDir: t.TempDir()}
+	if _, err := executeUpdateSymbolLifecycle(context.Background(), db, args); err != nil {
+		t.Fatal(err)
+	}
+
+	var dates []time.Time
+	err := db.QueryInto(model.RowQuery{
+		Table:   model.ViewStockFilledBFQ.Name,
+		Columns: []string{"date"},
+		OrderBy: []string{"date"},
+	}, &dates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{day("2024-01-02"), day("2024-01-03"), day("2024-01-04")}
+	if !reflect.DeepEqual(dates, want) {
+		t.Fatalf("filled view dates: got %v, want %v", dates, want)
+	}
+}
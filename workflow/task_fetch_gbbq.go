@@ -13,10 +13,11 @@ var TaskFetchGBBQ *Task
 
 func init() {
 	TaskFetchGBBQ = &Task{
-		Name:      "fetch_gbbq",
-		DependsOn: []string{},
-		SkipIf:    skipIfPlan(func(p *WorkPlan) bool { return !p.NeedGbbq }),
-		Executor:  executeFetchGBBQ,
+		Name:       "fetch_gbbq",
+		DependsOn:  []string{},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedGbbq }),
+		SkipReason: "plan.NeedGbbq=false，与日线同频无须更新",
+		Executor:   executeFetchGBBQ,
 	}
 	registerTask(TaskFetchGBBQ, "update")
 }
@@ -25,7 +26,7 @@ func init() {
 // gbbq 二进制 (gbbq-temp/gbbq) 供 update_gbbq 解码，
 // 内嵌的 zhb.zip (gbbq-temp/zhb.zip) 供 update_holidays 读取。
 func executeFetchGBBQ(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
-	fmt.Println("🐢 开始下载股本变迁数据")
+	utils.Logger(ctx).Info("🐢 开始下载股本变迁数据")
 
 	zipPath := filepath.Join(args.TempDir, "gbbq.zip")
 	gbbqURL := "http://www.tdx.com.cn/products/data/data/dbf/gbbq.zip"
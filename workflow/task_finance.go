@@ -0,0 +1,128 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/tdx"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+var TaskUpdateFinance *Task
+
+func init() {
+	TaskUpdateFinance = &Task{
+		Name:      "update_finance",
+		DependsOn: []string{},
+		Executor:  executeUpdateFinance,
+		OnError:   ErrorModeSkip,
+	}
+	registerTask(TaskUpdateFinance, "update")
+}
+
+// changedFinanceFiles 返回 hash 与已导入记录不同 (含未导入) 的 gpcw 文件。
+func changedFinanceFiles(db database.DataRepository, files []tdx.FinanceFileInfo) ([]tdx.FinanceFileInfo, error) {
+	var imported []model.FinanceFile
+	if err := db.QueryInto(model.RowQuery{Table: model.TableFinanceFile.TableName}, &imported); err != nil {
+		return nil, fmt.Errorf("failed to load imported finance files: %w", err)
+	}
+	hashes := make(map[string]string, len(imported))
+	for _, f := range imported {
+		hashes[f.Filename] = f.Hash
+	}
+
+	var changed []tdx.FinanceFileInfo
+	for _, f := range files {
+		if hashes[f.Filename] != f.Hash {
+			changed = append(changed, f)
+		}
+	}
+	return changed, nil
+}
+
+func executeUpdateFinance(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+
+	files, err := tdx.FetchFinanceFileList(ctx, args.TempDir)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := changedFinanceFiles(db, files)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		log.Info("🌲 财务数据无更新")
+		return &TaskResult{State: StateSkipped, Message: "finance files unchanged"}, nil
+	}
+
+	log.Info("🧾 开始更新财务数据", "files", len(changed), "total", len(files))
+	total := 0
+	for _, f := range changed {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := importFinanceFile(ctx, db, f, args.TempDir)
+		if err != nil {
+			return nil, err
+		}
+		total += n
+		log.Debug("财务数据导入", "file", f.Filename, "rows", n)
+	}
+
+	log.Info("🧾 财务数据导入成功", "files", len(changed), "rows", total)
+	return &TaskResult{State: StateCompleted, Rows: total, Message: "finance data imported"}, nil
+}
+
+// importFinanceFile 下载解析一期 gpcw，替换 raw_finance 中该报告期的数据并记下 hash。
+// 导入成功后才写 raw_finance_files，中途失败下次会重新处理该文件。
+func importFinanceFile(ctx context.Context, db database.DataRepository, f tdx.FinanceFileInfo, tempDir string) (int, error) {
+	datPath, err := tdx.DownloadFinanceFile(ctx, f, tempDir)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(datPath)
+
+	rows, err := tdx.DecodeFinanceFile(datPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode %s: %w", f.Filename, err)
+	}
+
+	dates := []time.Time{f.ReportDate}
+	if err := db.DeleteDates(model.TableFinance, "report_date", dates); err != nil {
+		return 0, err
+	}
+	if len(rows) > 0 {
+		csvPath := filepath.Join(tempDir, "finance.csv")
+		if err := tdx.WriteFinanceCSV(csvPath, rows); err != nil {
+			return 0, err
+		}
+		if err := db.ImportCSV(model.TableFinance, csvPath); err != nil {
+			return 0, fmt.Errorf("failed to import %s: %w", f.Filename, err)
+		}
+	}
+
+	if err := db.DeleteDates(model.TableFinanceFile, "report_date", dates); err != nil {
+		return 0, err
+	}
+	mark := []model.FinanceFile{{
+		ReportDate: f.ReportDate,
+		Filename:   f.Filename,
+		Hash:       f.Hash,
+		Size:       f.Size,
+		ImportedAt: time.Now(),
+	}}
+	markPath := filepath.Join(tempDir, "finance_files.csv")
+	if err := writeCSV(markPath, mark); err != nil {
+		return 0, err
+	}
+	if err := db.ImportCSV(model.TableFinanceFile, markPath); err != nil {
+		return 0, fmt.Errorf("failed to record %s: %w", f.Filename, err)
+	}
+	return len(rows), nil
+}
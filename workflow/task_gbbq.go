@@ -15,10 +15,11 @@ var TaskUpdateGBBQ *Task
 
 func init() {
 	TaskUpdateGBBQ = &Task{
-		Name:      "update_gbbq",
-		DependsOn: []string{"fetch_gbbq"},
-		SkipIf:    skipIfPlan(func(p *WorkPlan) bool { return !p.NeedGbbq }),
-		Executor:  executeUpdateGBBQ,
+		Name:       "update_gbbq",
+		DependsOn:  []string{"fetch_gbbq"},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedGbbq }),
+		SkipReason: "plan.NeedGbbq=false，与日线同频无须更新",
+		Executor:   executeUpdateGBBQ,
 	}
 	registerTask(TaskUpdateGBBQ, "update")
 }
@@ -45,6 +46,27 @@ func executeUpdateGBBQ(ctx context.Context, db database.DataRepository, args *Ta
 		return nil, fmt.Errorf("failed to import GBBQ csv into database: %w", err)
 	}
 
-	fmt.Println("📈 股本变迁数据导入成功")
+	dividends := model.DividendsFromGbbq(gbbqData)
+	if err := replaceTableRows(db, model.TableDividend, filepath.Join(args.TempDir, "dividend.csv"), dividends); err != nil {
+		return nil, err
+	}
+	shares := model.ShareCapitalFromGbbq(gbbqData)
+	if err := replaceTableRows(db, model.TableShareCapital, filepath.Join(args.TempDir, "share_capital.csv"), shares); err != nil {
+		return nil, err
+	}
+
+	utils.Logger(ctx).Info("📈 股本变迁数据导入成功", "rows", len(gbbqData), "dividends", len(dividends), "share_changes", len(shares))
 	return &TaskResult{State: StateCompleted, Rows: len(gbbqData), Message: "gbbq data imported"}, nil
 }
+
+// replaceTableRows 用 rows 整表替换 meta，供由 gbbq 派生的具名表使用。
+func replaceTableRows[T any](db database.DataRepository, meta *model.TableMeta, csvPath string, rows []T) error {
+	if err := writeCSV(csvPath, rows); err != nil {
+		return err
+	}
+	db.TruncateTable(meta)
+	if err := db.ImportCSV(meta, csvPath); err != nil {
+		return fmt.Errorf("failed to import %s: %w", meta.TableName, err)
+	}
+	return nil
+}
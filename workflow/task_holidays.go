@@ -3,31 +3,53 @@ package workflow
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
 	"github.com/jing2uo/tdx2db/tdx"
+	"github.com/jing2uo/tdx2db/utils"
 )
 
-var TaskUpdateHolidays *Task
+var (
+	TaskUpdateHolidays      *Task
+	TaskUpdateTradeCalendar *Task
+)
 
 func init() {
 	TaskUpdateHolidays = &Task{
-		Name:      "update_holidays",
-		DependsOn: []string{"fetch_gbbq"},
-		SkipIf:    skipIfPlan(func(p *WorkPlan) bool { return !p.NeedHolidays }),
-		Executor:  executeUpdateHolidays,
-		OnError:   ErrorModeSkip,
+		Name:       "update_holidays",
+		DependsOn:  []string{"fetch_gbbq"},
+		SkipIf:     skipIfPlan(func(p *WorkPlan) bool { return !p.NeedHolidays }),
+		SkipReason: "plan.NeedHolidays=false，与 gbbq 同频无须更新",
+		Executor:   executeUpdateHolidays,
+		OnError:    ErrorModeSkip,
 	}
 	registerTask(TaskUpdateHolidays, "update")
+
+	// 节假日不变时日历也不变；表为空 (新库或刚升级) 时补建一次。
+	TaskUpdateTradeCalendar = &Task{
+		Name:      "update_trade_calendar",
+		DependsOn: []string{"update_holidays"},
+		SkipIf: func(ctx context.Context, db database.DataRepository, args *TaskArgs) bool {
+			if args.Plan == nil || args.Plan.NeedHolidays {
+				return false
+			}
+			latest, err := db.GetLatestDate(model.TableTradeCalendar.TableName, "date")
+			return err == nil && !latest.IsZero()
+		},
+		SkipReason: "plan.NeedHolidays=false，交易日历已生成",
+		Executor:   executeUpdateTradeCalendar,
+		OnError:    ErrorModeSkip,
+	}
+	registerTask(TaskUpdateTradeCalendar, "update")
 }
 
 func executeUpdateHolidays(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
 	zhbZipPath := filepath.Join(args.TempDir, "gbbq-temp", "zhb.zip")
 	holidaysFile, err := tdx.ExportTdxHolidaysToCSV(zhbZipPath, args.TempDir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "🚨 警告: %v\n", err)
+		utils.Logger(ctx).Warn("🚨 警告", "error", err)
 		return &TaskResult{State: StateFailed, Error: err, Message: "holidays import warning"}, nil
 	}
 
@@ -35,6 +57,27 @@ func executeUpdateHolidays(ctx context.Context, db database.DataRepository, args
 		return nil, fmt.Errorf("failed to import holidays: %w", err)
 	}
 
-	fmt.Println("🗓️  交易日历导入成功")
+	utils.Logger(ctx).Info("🗓️  交易日历导入成功")
 	return &TaskResult{State: StateCompleted, Message: "holidays data imported"}, nil
 }
+
+// executeUpdateTradeCalendar 用 raw_holidays 重建 raw_trade_calendar，
+// 覆盖上交所开业日到节假日数据与今天中较晚一年的年末。
+func executeUpdateTradeCalendar(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	holidays, err := db.GetHolidays()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load holidays: %w", err)
+	}
+	if len(holidays) == 0 {
+		return &TaskResult{State: StateSkipped, Message: "no holidays"}, nil
+	}
+
+	cal := NewTradingCalendar(holidays)
+	rows := cal.Rows(CalendarStart, cal.CalendarEnd(args.Today))
+	if err := replaceTableRows(db, model.TableTradeCalendar, filepath.Join(args.TempDir, "trade_calendar.csv"), rows); err != nil {
+		return nil, err
+	}
+
+	utils.Logger(ctx).Info("🗓️  交易日历表生成成功", "rows", len(rows))
+	return &TaskResult{State: StateCompleted, Rows: len(rows), Message: "trade calendar rebuilt"}, nil
+}
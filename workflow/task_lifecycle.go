@@ -0,0 +1,77 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+var (
+	TaskUpdateSymbolLifecycle *Task
+	TaskInitSymbolLifecycle   *Task
+)
+
+func init() {
+	// 代码表抓取失败时仍按日线刷新起止日期与停牌状态，在线跟踪字段沿用上一轮。
+	TaskUpdateSymbolLifecycle = &Task{
+		Name:      "update_symbol_lifecycle",
+		DependsOn: []string{"update_daily", "fill_daily_online", "update_symbol_names"},
+		Executor:  executeUpdateSymbolLifecycle,
+		OnError:   ErrorModeSkip,
+	}
+	registerTask(TaskUpdateSymbolLifecycle, "update")
+
+	// init 后即按日线建好起止日期，v_*_filled_* 等视图不必等第一次 cron；退市状态待 cron 抓取代码表后标记。
+	TaskInitSymbolLifecycle = &Task{
+		Name:      "init_symbol_lifecycle",
+		DependsOn: []string{"init_daily"},
+		Executor:  executeUpdateSymbolLifecycle,
+		OnError:   ErrorModeSkip,
+	}
+	registerTask(TaskInitSymbolLifecycle, "init")
+}
+
+func executeUpdateSymbolLifecycle(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+	log.Info("🧬 更新证券生命周期")
+
+	var prev []model.SymbolLifecycle
+	if err := db.QueryInto(model.RowQuery{Table: model.TableSymbolLifecycle.TableName}, &prev); err != nil {
+		return nil, fmt.Errorf("failed to query symbol lifecycle: %w", err)
+	}
+
+	var ranges []model.SymbolLifecycle
+	err := db.QueryInto(model.RowQuery{
+		Table:   model.TableKlineDaily.TableName,
+		Columns: []string{"symbol", "min(date) AS first_date", "max(date) AS last_date"},
+		GroupBy: []string{"symbol"},
+	}, &ranges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily date ranges: %w", err)
+	}
+
+	if args.OnlineSymbols == nil && len(prev) > 0 {
+		log.Warn("⚠️ 本轮没有在线代码表，退市状态沿用上一轮")
+	}
+	rows := model.UpdateSymbolLifecycle(prev, ranges, args.OnlineSymbols, args.Today)
+	if len(rows) == 0 {
+		return &TaskResult{State: StateSkipped, Message: "no symbols"}, nil
+	}
+	if err := replaceTableRows(db, model.TableSymbolLifecycle, filepath.Join(args.TempDir, "symbol_lifecycle.csv"), rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, r := range rows {
+		counts[r.Status]++
+	}
+	log.Info("🚀 证券生命周期更新成功", "rows", len(rows),
+		"listed", counts[model.StatusListed],
+		"suspended", counts[model.StatusSuspended],
+		"delisted", counts[model.StatusDelisted])
+	return &TaskResult{State: StateCompleted, Rows: len(rows), Message: "symbol lifecycle updated"}, nil
+}
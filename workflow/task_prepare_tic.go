@@ -3,11 +3,11 @@ package workflow
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 
 	"github.com/jing2uo/tdx2db/database"
 	"github.com/jing2uo/tdx2db/model"
 	"github.com/jing2uo/tdx2db/tdx"
+	"github.com/jing2uo/tdx2db/utils"
 )
 
 // ExtraTicValidDates 是 prepare_tic 写入 args.Extra 的 key，
@@ -23,7 +23,8 @@ func init() {
 		SkipIf: func(ctx context.Context, db database.DataRepository, args *TaskArgs) bool {
 			return !args.Min
 		},
-		Executor: executePrepareTic,
+		SkipReason: "未指定 --min",
+		Executor:   executePrepareTic,
 	}
 	registerTask(TaskPrepareTic, "update")
 }
@@ -33,19 +34,16 @@ func executePrepareTic(ctx context.Context, db database.DataRepository, args *Ta
 	if err != nil {
 		return nil, fmt.Errorf("query 1min latest: %w", err)
 	}
+	log := utils.Logger(ctx)
 	if latest.IsZero() {
-		fmt.Println("🛑 数据库中没有分时数据，历史请自行导入")
+		log.Warn("🛑 数据库中没有分时数据，历史请自行导入")
 		latest = args.Today.AddDate(0, 0, -1)
 	} else {
-		fmt.Printf("📅 分时数据最新日期为 %s\n", latest.Format("2006-01-02"))
+		log.Info(fmt.Sprintf("📅 分时数据最新日期为 %s", latest.Format("2006-01-02")),
+			"date", latest.Format("2006-01-02"))
 	}
 
-	src := pullSource{
-		targetDir:   filepath.Join(args.VipdocDir, "newdatetick"),
-		urlTemplate: "https://www.tdx.com.cn/products/data/data/g4tic/%s.zip",
-		fileSuffix:  "tic",
-		label:       "分时",
-	}
+	src := ticSource(args)
 	validDates, err := pullDateRange(ctx, latest, src, args)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare tic data: %w", err)
@@ -71,8 +69,8 @@ func executePrepareTic(ctx context.Context, db database.DataRepository, args *Ta
 	}
 
 	endDate := validDates[len(validDates)-1]
-	fmt.Printf("🐌 开始转档分笔数据\n")
-	if err := tdx.DatatoolCreate(args.TempDir, "tick", endDate); err != nil {
+	log.Info("🐌 开始转档分笔数据", "date", endDate.Format("2006-01-02"))
+	if err := tdx.DatatoolCreate(ctx, args.TempDir, "tick", endDate); err != nil {
 		return nil, fmt.Errorf("failed to run DatatoolTickCreate: %w", err)
 	}
 
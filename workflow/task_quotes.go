@@ -0,0 +1,67 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jing2uo/tdx2db/database"
+	"github.com/jing2uo/tdx2db/model"
+	"github.com/jing2uo/tdx2db/tdx"
+	"github.com/jing2uo/tdx2db/utils"
+)
+
+var TaskSnapshotQuotes *Task
+
+func init() {
+	TaskSnapshotQuotes = &Task{
+		Name:      "snapshot_quotes",
+		DependsOn: []string{"update_symbol_names"},
+		SkipIf: func(ctx context.Context, db database.DataRepository, args *TaskArgs) bool {
+			return !args.Quotes
+		},
+		SkipReason: "未指定 --quotes",
+		Executor: func(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
+			return SnapshotQuotes(ctx, db, args.TempDir, nil)
+		},
+		OnError: ErrorModeSkip,
+	}
+	registerTask(TaskSnapshotQuotes, "update")
+}
+
+// quoteClasses 是不指定代码时抓取实时行情的默认分类。
+var quoteClasses = []string{model.ClassStock, model.ClassBStock, model.ClassETF, model.ClassIndex}
+
+// SnapshotQuotes 抓取一次实时行情追加到 raw_quote_snapshot。
+// symbols 为空时取 raw_symbol_name 中 quoteClasses 的全部代码。
+func SnapshotQuotes(ctx context.Context, db database.DataRepository, tempDir string, symbols []string) (*TaskResult, error) {
+	log := utils.Logger(ctx)
+
+	if len(symbols) == 0 {
+		var err error
+		if symbols, err = db.GetSymbolsByClass(quoteClasses...); err != nil {
+			return nil, err
+		}
+	}
+	if len(symbols) == 0 {
+		log.Info("🌲 无可抓取实时行情的代码")
+		return &TaskResult{State: StateSkipped, Message: "no symbols"}, nil
+	}
+
+	log.Info("📡 抓取实时行情快照", "symbols", len(symbols))
+	rows, err := tdx.FetchOnlineQuotes(ctx, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch quotes: %w", err)
+	}
+
+	csvPath := filepath.Join(tempDir, "quote_snapshot.csv")
+	if err := writeCSV(csvPath, rows); err != nil {
+		return nil, err
+	}
+	if err := db.ImportCSV(model.TableQuoteSnapshot, csvPath); err != nil {
+		return nil, fmt.Errorf("failed to import quote snapshot: %w", err)
+	}
+
+	log.Info("📡 实时行情快照写入完成", "rows", len(rows))
+	return &TaskResult{State: StateCompleted, Rows: len(rows), Message: "quote snapshot saved"}, nil
+}
@@ -24,7 +24,8 @@ func init() {
 }
 
 func executeUpdateSymbolNames(ctx context.Context, db database.DataRepository, args *TaskArgs) (*TaskResult, error) {
-	fmt.Println("🧩 开始下载代码名称")
+	log := utils.Logger(ctx)
+	log.Info("🧩 开始下载代码名称")
 
 	names, err := tdx.FetchOnlineSymbolNames(ctx)
 	if err != nil {
@@ -46,7 +47,14 @@ func executeUpdateSymbolNames(ctx context.Context, db database.DataRepository, a
 		return nil, fmt.Errorf("failed to import symbol name csv: %w", err)
 	}
 
+	args.OnlineSymbols = names
+
+	links := model.MatchCBondUnderlying(names)
+	if err := replaceTableRows(db, model.TableCBondUnderlying, filepath.Join(args.TempDir, "cbond_underlying.csv"), links); err != nil {
+		return nil, err
+	}
+
 	msg := fmt.Sprintf("symbol names imported: %d rows", len(names))
-	fmt.Printf("🚀 代码名称导入成功\n")
+	log.Info("🚀 代码名称导入成功", "rows", len(names), "cbond_links", len(links))
 	return &TaskResult{State: StateCompleted, Rows: len(names), Message: msg}, nil
 }
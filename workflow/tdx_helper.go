@@ -19,14 +19,62 @@ type pullSource struct {
 	label       string // 日志中文标签，例如 "日线" / "分时"
 }
 
+// tdxDataURL 是 g4day / g4tic zip 的下载根地址，测试中替换为本地服务。
+var tdxDataURL = "https://www.tdx.com.cn/products/data/data"
+
+// dailySource / ticSource 是 update_daily / prepare_tic 使用的下载源，
+// dry-run 探测复用同一份定义，保证探测的 URL 与真实下载一致。
+func dailySource(args *TaskArgs) pullSource {
+	return pullSource{
+		targetDir:   filepath.Join(args.VipdocDir, "refmhq"),
+		urlTemplate: tdxDataURL + "/g4day/%s.zip",
+		fileSuffix:  "day",
+		label:       "日线",
+	}
+}
+
+func ticSource(args *TaskArgs) pullSource {
+	return pullSource{
+		targetDir:   filepath.Join(args.VipdocDir, "newdatetick"),
+		urlTemplate: tdxDataURL + "/g4tic/%s.zip",
+		fileSuffix:  "tic",
+		label:       "分时",
+	}
+}
+
+// pendingDates 返回 since 之后到 today (含) 的逐日日期。
+func pendingDates(since, today time.Time) []time.Time {
+	var dates []time.Time
+	for d := since.Add(24 * time.Hour); !d.After(today); d = d.Add(24 * time.Hour) {
+		dates = append(dates, d)
+	}
+	return dates
+}
+
+// missingDateNote 解释某日 zip 404 的原因：节假日 / 周末 / 当日未发布 / 尚未发布。
+func missingDateNote(date time.Time, args *TaskArgs) string {
+	dateStr := date.Format("20060102")
+	var cal *TradingCalendar
+	if args.Plan != nil {
+		cal = args.Plan.Calendar
+	}
+	switch {
+	case cal != nil && cal.IsHoliday(date):
+		return fmt.Sprintf("🎉 %s 为节假日，跳过", dateStr)
+	case cal != nil && cal.IsWeekend(date):
+		return fmt.Sprintf("🌴 %s 为周末，跳过", dateStr)
+	case date.Equal(args.Today):
+		return fmt.Sprintf("⏳ %s 数据尚未发布，请等待收盘后重试", dateStr)
+	default:
+		return fmt.Sprintf("🟡 %s 数据尚未发布", dateStr)
+	}
+}
+
 // pullDateRange 从 since 之后到 today 之间逐日下载 zip 并解压。
 // 404 状态会结合 args.Plan.Calendar 区分"节假日跳过"/"数据尚未发布"。
 // 返回实际成功下载（200）的日期列表，调用方据此决定后续转档/导入。
 func pullDateRange(ctx context.Context, since time.Time, src pullSource, args *TaskArgs) ([]time.Time, error) {
-	var dates []time.Time
-	for d := since.Add(24 * time.Hour); !d.After(args.Today); d = d.Add(24 * time.Hour) {
-		dates = append(dates, d)
-	}
+	dates := pendingDates(since, args.Today)
 	if len(dates) == 0 {
 		return nil, nil
 	}
@@ -35,7 +83,8 @@ func pullDateRange(ctx context.Context, since time.Time, src pullSource, args *T
 		return nil, fmt.Errorf("failed to create target directory: %w", err)
 	}
 
-	fmt.Printf("🐌 开始下载%s数据\n", src.label)
+	log := utils.Logger(ctx)
+	log.Info(fmt.Sprintf("🐌 开始下载%s数据", src.label), "dates", len(dates))
 
 	validDates := make([]time.Time, 0, len(dates))
 
@@ -54,27 +103,14 @@ func pullDateRange(ctx context.Context, since time.Time, src pullSource, args *T
 		status, err := utils.DownloadFile(url, filePath)
 		switch status {
 		case 200:
-			fmt.Printf("✅ 已下载 %s 的数据\n", dateStr)
+			log.Info(fmt.Sprintf("✅ 已下载 %s 的数据", dateStr), "date", dateStr, "source", src.fileSuffix)
 			if err := utils.UnzipFile(filePath, src.targetDir); err != nil {
-				fmt.Printf("⚠️ 解压文件 %s 失败: %v\n", filePath, err)
+				log.Warn(fmt.Sprintf("⚠️ 解压文件 %s 失败", filePath), "date", dateStr, "error", err)
 				continue
 			}
 			validDates = append(validDates, date)
 		case 404:
-			var cal *TradingCalendar
-			if args.Plan != nil {
-				cal = args.Plan.Calendar
-			}
-			switch {
-			case cal != nil && cal.IsHoliday(date):
-				fmt.Printf("🎉 %s 为节假日，跳过\n", dateStr)
-			case cal != nil && cal.IsWeekend(date):
-				fmt.Printf("🌴 %s 为周末，跳过\n", dateStr)
-			case date.Equal(args.Today):
-				fmt.Printf("⏳ %s 数据尚未发布，请等待收盘后重试\n", dateStr)
-			default:
-				fmt.Printf("🟡 %s 数据尚未发布\n", dateStr)
-			}
+			log.Info(missingDateNote(date, args), "date", dateStr, "source", src.fileSuffix, "status", status)
 			continue
 		default:
 			if err != nil {